@@ -5,49 +5,130 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"time"
 
-	"github.com/leonelquinteros/gotext"
 	"golang.org/x/text/language"
 )
 
-const (
-	pluralForm = `"Plural-Forms: nplurals=2; plural=(n != 1);\n"` + "\n\n"
-)
+// defaultTranslator backs the package level functions.
+var defaultTranslator = NewTranslator()
 
-var translations = map[string]*gotext.Po{}
+// Default returns the translator used by the package level functions, to be
+// layered on top of or below other translators.
+func Default() *Translator {
+	return defaultTranslator
+}
 
 func Tr(locale language.Tag, key string, args ...interface{}) string {
-	translation, ok := translations[locale.String()]
-	if !ok {
-		return fmt.Sprintf(key, args...)
-	}
-	return translation.Get(key, args...)
+	return defaultTranslator.Tr(locale, key, args...)
 }
 
 func Trn(locale language.Tag, count int, singular string, plural string, args ...interface{}) string {
-	translation, ok := translations[locale.String()]
-	if !ok {
-		key := singular
-		if count > 1 {
-			key = plural
-		}
-		return fmt.Sprintf(key, args...)
+	return defaultTranslator.Trn(locale, count, singular, plural, args...)
+}
+
+func Trc(locale language.Tag, context string, key string, args ...interface{}) string {
+	return defaultTranslator.Trc(locale, context, key, args...)
+}
+
+func Trnc(locale language.Tag, context string, count int, singular string, plural string, args ...interface{}) string {
+	return defaultTranslator.Trnc(locale, context, count, singular, plural, args...)
+}
+
+// Trf translates an ICU MessageFormat key with the package level translator.
+func Trf(locale language.Tag, key string, args Args) string {
+	return defaultTranslator.Trf(locale, key, args)
+}
+
+func format(text string, args ...interface{}) string {
+	if len(args) == 0 {
+		return text
 	}
-	return translation.GetN(singular, plural, count, args...)
+	return fmt.Sprintf(text, args...)
 }
 
 func Load(bundle string) error {
+	return defaultTranslator.Load(bundle)
+}
+
+func LoadDir(bundleDir string) error {
+	return defaultTranslator.LoadDir(bundleDir)
+}
+
+// LoadReader adds a .zip bundle read from memory to the package level
+// translator.
+func LoadReader(bundle io.ReaderAt, size int64) error {
+	return defaultTranslator.LoadReader(bundle, size)
+}
+
+// LoadFS adds the bundles of a file system to the package level translator.
+func LoadFS(fs http.FileSystem) error {
+	return defaultTranslator.LoadFS(fs)
+}
+
+// SetDefaultLocale sets the default locale of the package level translator.
+func SetDefaultLocale(locale language.Tag) {
+	defaultTranslator.SetDefaultLocale(locale)
+}
+
+// Locales returns the locales loaded in the package level translator.
+func Locales() []language.Tag {
+	return defaultTranslator.Locales()
+}
+
+// Supports reports whether the package level translator can serve the locale.
+func Supports(locale language.Tag) bool {
+	return defaultTranslator.Supports(locale)
+}
+
+// OnMissing sets the handler called when a lookup of the package level
+// translator misses.
+func OnMissing(handler MissingHandler) {
+	defaultTranslator.OnMissing(handler)
+}
+
+// OnInvalid sets the handler called with the invalid ICU translations found
+// when the package level translator loads bundles.
+func OnInvalid(handler InvalidHandler) {
+	defaultTranslator.OnInvalid(handler)
+}
+
+// Reload reloads the bundles of the package level translator.
+func Reload() error {
+	return defaultTranslator.Reload()
+}
+
+// Watch reloads the bundles of the package level translator when they change.
+func Watch(interval time.Duration, onError func(error)) (stop func()) {
+	return defaultTranslator.Watch(interval, onError)
+}
+
+func loadZip(bundle string, translations map[string]*messages) error {
 	reader, err := zip.OpenReader(bundle)
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	for _, entry := range reader.File {
-		if !entry.FileInfo().IsDir() && filepath.Ext(entry.Name) == ".po" {
+	return loadZipFiles(reader.File, translations)
+}
+
+func loadZipReader(bundle io.ReaderAt, size int64, translations map[string]*messages) error {
+	reader, err := zip.NewReader(bundle, size)
+	if err != nil {
+		return err
+	}
+	return loadZipFiles(reader.File, translations)
+}
+
+func loadZipFiles(files []*zip.File, translations map[string]*messages) error {
+	for _, entry := range files {
+		if !entry.FileInfo().IsDir() && IsBundleFile(entry.Name) {
 
 			err := func() error {
 				file, err := entry.Open()
@@ -55,7 +136,7 @@ func Load(bundle string) error {
 					return err
 				}
 				defer file.Close()
-				return addTranslation(path.Dir(entry.Name), file)
+				return addTranslation(translations, path.Dir(entry.Name), entry.Name, file)
 			}()
 			if err != nil {
 				return err
@@ -66,9 +147,12 @@ func Load(bundle string) error {
 	return nil
 }
 
-func LoadDir(bundleDir string) error {
-	err := filepath.Walk(bundleDir, func(fullpath string, f os.FileInfo, err error) error {
-		if !f.IsDir() && filepath.Ext(fullpath) == ".po" {
+func loadDir(bundleDir string, translations map[string]*messages) error {
+	return filepath.Walk(bundleDir, func(fullpath string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.IsDir() && IsBundleFile(fullpath) {
 			return func() error {
 				fd, err := os.Open(fullpath)
 				if err != nil {
@@ -78,22 +162,78 @@ func LoadDir(bundleDir string) error {
 				// determine locale
 				dir, _ := path.Split(fullpath)
 				locale := path.Base(dir)
-				return addTranslation(locale, fd)
+				return addTranslation(translations, locale, fullpath, fd)
 
 			}()
 		}
 		return nil
 	})
-	return err
 }
 
-func addTranslation(locale string, reader io.Reader) error {
+// loadFS walks the file system from its root, loading every bundle file under
+// the locale of its directory like loadDir does.
+func loadFS(fs http.FileSystem, translations map[string]*messages) error {
+	return walkFS(fs, "/", func(name string) error {
+		file, err := fs.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return addTranslation(translations, path.Base(path.Dir(name)), name, file)
+	})
+}
+
+func walkFS(fs http.FileSystem, name string, fn func(name string) error) error {
+	dir, err := fs.Open(name)
+	if err != nil {
+		return err
+	}
+	infos, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil {
+		return err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+	for _, info := range infos {
+		fullpath := path.Join(name, info.Name())
+		if info.IsDir() {
+			err = walkFS(fs, fullpath, fn)
+		} else if IsBundleFile(fullpath) {
+			err = fn(fullpath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTranslation adds the named .po, .mo or .json file to the translations
+// of the locale.
+func addTranslation(translations map[string]*messages, locale string, name string, reader io.Reader) error {
 	content, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return err
 	}
-	po := new(gotext.Po)
-	po.Parse([]byte(pluralForm + string(content)))
-	translations[locale] = po
+	content, err = DecodeFile(name, content)
+	if err != nil {
+		return err
+	}
+	// index by the canonical tag so es_AR, es-ar and es-AR bundles are all found
+	tag, err := language.Parse(locale)
+	if err == nil {
+		locale = tag.String()
+	}
+	// the files of a locale add up, each one overriding the entries of the
+	// files before it
+	if loaded, ok := translations[locale]; ok {
+		content = mergeContents(loaded.content, content)
+	}
+	messages, err := newMessages(tag, content)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	translations[locale] = messages
 	return nil
 }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// apiClient talks to the Babel API, retrying the requests that fail with
+// network or server errors.
+type apiClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+}
+
+// statusError is returned for the responses with a non-2xx status code.
+type statusError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %d: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+func newAPIClient(baseURL, apiKey string, timeout time.Duration, retries int) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: timeout},
+		retries: retries,
+		backoff: 500 * time.Millisecond,
+	}
+}
+
+// clientFromConfig builds the client from the --url, --apikey, --timeout and
+// --retries values.
+func clientFromConfig() *apiClient {
+	return newAPIClient(viper.GetString("url"), viper.GetString("apikey"), viper.GetDuration("timeout"), viper.GetInt("retries"))
+}
+
+// Upload posts the zipped source messages of the app.
+func (c *apiClient) Upload(app, project, contentType string, body []byte) ([]byte, error) {
+	path := fmt.Sprintf("/apps/%s/sources?project_name=%s&force=false", url.PathEscape(app), url.QueryEscape(project))
+	return c.do(http.MethodPost, path, contentType, body)
+}
+
+// Download fetches the translation bundle of the app, failing if the response
+// is not a zip file.
+func (c *apiClient) Download(app string) ([]byte, error) {
+	bundle, err := c.do(http.MethodGet, fmt.Sprintf("/apps/%s/translations", url.PathEscape(app)), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle))); err != nil {
+		return nil, fmt.Errorf("the downloaded bundle is not a valid zip file: %v", err)
+	}
+	return bundle, nil
+}
+
+func (c *apiClient) do(method, path, contentType string, body []byte) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.backoff << uint(attempt-1))
+		}
+
+		var content []byte
+		var retry bool
+		content, retry, err = c.send(method, path, contentType, body)
+		if err == nil || !retry {
+			return content, err
+		}
+	}
+	return nil, err
+}
+
+// send makes a single request, reporting whether it is worth retrying when it
+// fails.
+func (c *apiClient) send(method, path, contentType string, body []byte) ([]byte, bool, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, &statusError{Method: method, URL: req.URL.String(), Status: resp.StatusCode, Body: string(content)}
+	}
+	return content, false, nil
+}
+
+// replaceFile writes the content next to the destination and renames it, so
+// the destination is never left half written.
+func replaceFile(destination string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(destination), "."+filepath.Base(destination))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), destination)
+}
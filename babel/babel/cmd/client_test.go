@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(server *httptest.Server, retries int) *apiClient {
+	client := newAPIClient(server.URL+"/", "secret", time.Second, retries)
+	client.backoff = time.Millisecond
+	return client
+}
+
+func zipBundle(t *testing.T) []byte {
+	buffer := bytes.NewBuffer(nil)
+	writer := zip.NewWriter(buffer)
+	file, err := writer.Create("es-AR/messages.po")
+	require.NoError(t, err)
+	file.Write([]byte(`msgid "Hello"` + "\n" + `msgstr "Hola"` + "\n"))
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestClientDownload(t *testing.T) {
+	bundle := zipBundle(t)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.Equal(t, "/apps/my-app/translations", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(bundle)
+	}))
+	defer server.Close()
+
+	content, err := testClient(server, 2).Download("my-app")
+	require.NoError(t, err)
+	require.Equal(t, bundle, content)
+	require.Equal(t, 3, calls)
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		retries int
+		calls   int
+	}{
+		{name: "server errors are retried", status: http.StatusInternalServerError, body: "oops", retries: 2, calls: 3},
+		{name: "client errors are not retried", status: http.StatusUnauthorized, body: "invalid key", retries: 2, calls: 1},
+		{name: "an invalid zip is rejected", status: http.StatusOK, body: "<html>maintenance</html>", retries: 2, calls: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := testClient(server, test.retries).Download("my-app")
+			require.Error(t, err)
+			require.Equal(t, test.calls, calls)
+			if test.status != http.StatusOK {
+				status, ok := err.(*statusError)
+				require.True(t, ok)
+				require.Equal(t, test.status, status.Status)
+				require.Equal(t, test.body, status.Body)
+			}
+		})
+	}
+}
+
+func TestClientUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/apps/my-app/sources", r.URL.Path)
+		require.Equal(t, "my project", r.URL.Query().Get("project_name"))
+		require.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(r.Body)
+		require.Equal(t, "messages", string(body))
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := testClient(server, 0).Upload("my-app", "my project", "text/plain", []byte("messages"))
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(resp))
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	destination := filepath.Join(dir, "all.zip")
+	require.NoError(t, ioutil.WriteFile(destination, []byte("old"), 0666))
+	require.NoError(t, replaceFile(destination, []byte("new")))
+
+	content, err := ioutil.ReadFile(destination)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RootCmd.AddCommand(coverageCommand)
+
+	coverageCommand.Flags().String("format", "text", "output format, text or json")
+	coverageCommand.Flags().Float64("min-coverage", 0, "exit with an error when a locale coverage, in percent, is below this value")
+	viper.BindPFlag("format", coverageCommand.Flags().Lookup("format"))
+	viper.BindPFlag("min-coverage", coverageCommand.Flags().Lookup("min-coverage"))
+}
+
+// localeCoverage is the translation completeness of a locale of the bundle.
+type localeCoverage struct {
+	Locale     string   `json:"locale"`
+	Total      int      `json:"total"`
+	Translated int      `json:"translated"`
+	Coverage   float64  `json:"coverage"`
+	Missing    []string `json:"missing"`
+	Empty      []string `json:"empty"`
+	Fuzzy      []string `json:"fuzzy"`
+}
+
+var coverageCommand = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report the translation coverage.",
+	Long:  "Compare the messages file against the message bundle and report the missing, empty and fuzzy translations per locale",
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := po.ParseFile(flag(cmd, "messages"))
+		assert(err, "failed to read the messages file")
+
+		bundle, err := po.ReadBundle(flag(cmd, "bundle"))
+		assert(err, "failed to read the messages bundle")
+
+		report := coverage(source, bundle)
+		switch format := viper.GetString("format"); format {
+		case "json":
+			err = json.NewEncoder(os.Stdout).Encode(report)
+		case "text":
+			err = printCoverage(os.Stdout, report)
+		default:
+			err = fmt.Errorf("unknown format %s", format)
+		}
+		assert(err, "failed to write the coverage report")
+
+		minimum := viper.GetFloat64("min-coverage")
+		for _, locale := range report {
+			if locale.Coverage < minimum {
+				fmt.Fprintf(os.Stderr, "%s coverage %.1f%% is below %.1f%%\n", locale.Locale, locale.Coverage, minimum)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// coverage checks every message of the source against each locale of the
+// bundle, sorting the locales and keys of the report.
+func coverage(source *po.File, bundle map[string]*po.File) []localeCoverage {
+	messages := source.Messages()
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	report := make([]localeCoverage, 0, len(bundle))
+	for locale, file := range bundle {
+		translations := file.Messages()
+		result := localeCoverage{
+			Locale:  locale,
+			Total:   len(keys),
+			Missing: []string{},
+			Empty:   []string{},
+			Fuzzy:   []string{},
+		}
+
+		for _, key := range keys {
+			label := messages[key].Label()
+			translation, ok := translations[key]
+			switch {
+			case !ok:
+				result.Missing = append(result.Missing, label)
+			case !translation.IsTranslated():
+				result.Empty = append(result.Empty, label)
+			case translation.HasFlag("fuzzy"):
+				result.Fuzzy = append(result.Fuzzy, label)
+			default:
+				result.Translated++
+			}
+		}
+
+		result.Coverage = 100
+		if result.Total > 0 {
+			result.Coverage = 100 * float64(result.Translated) / float64(result.Total)
+		}
+		report = append(report, result)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Locale < report[j].Locale
+	})
+	return report
+}
+
+func printCoverage(w io.Writer, report []localeCoverage) error {
+	for _, locale := range report {
+		_, err := fmt.Fprintf(w, "%s\t%.1f%%\t%d/%d translated, %d missing, %d empty, %d fuzzy\n",
+			locale.Locale, locale.Coverage, locale.Translated, locale.Total,
+			len(locale.Missing), len(locale.Empty), len(locale.Fuzzy))
+		if err != nil {
+			return err
+		}
+
+		for _, group := range []struct {
+			name string
+			keys []string
+		}{{"missing", locale.Missing}, {"empty", locale.Empty}, {"fuzzy", locale.Fuzzy}} {
+			for _, key := range group.keys {
+				if _, err := fmt.Fprintf(w, "\t%s: %q\n", group.name, key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/stretchr/testify/require"
+)
+
+func parsePo(t *testing.T, content string) *po.File {
+	file, err := po.Parse(strings.NewReader(content))
+	require.NoError(t, err)
+	return file
+}
+
+func TestCoverage(t *testing.T) {
+	source := parsePo(t, `
+msgid "Buy"
+msgstr "Buy"
+
+msgid "Sell"
+msgstr "Sell"
+
+msgctxt "door"
+msgid "Open"
+msgstr "Open"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d item"
+msgstr[1] "%d items"
+`)
+
+	bundle := map[string]*po.File{
+		"pt-BR": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+
+msgid "Sell"
+msgstr "Vender"
+
+msgctxt "door"
+msgid "Open"
+msgstr "Abrir"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d item"
+msgstr[1] "%d itens"
+`),
+		"es-AR": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+
+#, fuzzy
+msgid "Sell"
+msgstr "Vender"
+
+msgid "Open"
+msgstr "Abrir"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d ítem"
+msgstr[1] ""
+`),
+	}
+
+	report := coverage(source, bundle)
+	require.Len(t, report, 2)
+
+	es := report[0]
+	require.Equal(t, "es-AR", es.Locale)
+	require.Equal(t, 4, es.Total)
+	require.Equal(t, 1, es.Translated)
+	require.Equal(t, 25.0, es.Coverage)
+	require.Equal(t, []string{"Open [door]"}, es.Missing)
+	require.Equal(t, []string{"%d item"}, es.Empty)
+	require.Equal(t, []string{"Sell"}, es.Fuzzy)
+
+	pt := report[1]
+	require.Equal(t, "pt-BR", pt.Locale)
+	require.Equal(t, 100.0, pt.Coverage)
+	require.Empty(t, pt.Missing)
+}
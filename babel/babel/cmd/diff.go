@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(diffCommand)
+}
+
+// messageDiff lists the keys added, removed and changed between two sets of
+// messages.
+type messageDiff struct {
+	Locale  string
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether both sets had the same messages.
+func (d messageDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+var diffCommand = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compare two bundles or messages files.",
+	Long:  "List, per locale, the keys added, removed or changed between two .zip bundles, bundle directories or .po, .mo or .json files",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := readMessages(args[0])
+		assert(err, "failed to read "+args[0])
+
+		b, err := readMessages(args[1])
+		assert(err, "failed to read "+args[1])
+
+		printDiffs(os.Stdout, diffBundles(a, b))
+	},
+}
+
+// readMessages reads a messages file, indexed under an empty locale, or every
+// locale of a bundle.
+func readMessages(path string) (map[string]*po.File, error) {
+	if po.IsBundleFile(path) {
+		file, err := po.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*po.File{"": file}, nil
+	}
+	return po.ReadBundle(path)
+}
+
+// diffBundles compares the translations of every locale found in a or b. A
+// single messages file compared against a bundle is compared against each of
+// its locales, by key only since its msgstr are not translations.
+func diffBundles(a map[string]*po.File, b map[string]*po.File) []messageDiff {
+	translations := true
+	if single, ok := a[""]; ok && len(a) == 1 && len(b) > 0 {
+		a, translations = sameFile(single, b), false
+	} else if single, ok := b[""]; ok && len(b) == 1 && len(a) > 0 {
+		b, translations = sameFile(single, a), false
+	}
+
+	locales := map[string]bool{}
+	for locale := range a {
+		locales[locale] = true
+	}
+	for locale := range b {
+		locales[locale] = true
+	}
+
+	var diffs []messageDiff
+	for _, locale := range sortedKeys(locales) {
+		diff := diffMessages(messages(a[locale]), messages(b[locale]), translations)
+		diff.Locale = locale
+		if !diff.Empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// sameFile maps the file to every locale of the bundle.
+func sameFile(file *po.File, bundle map[string]*po.File) map[string]*po.File {
+	files := make(map[string]*po.File, len(bundle))
+	for locale := range bundle {
+		files[locale] = file
+	}
+	return files
+}
+
+func messages(file *po.File) map[string]*po.Entry {
+	if file == nil {
+		return map[string]*po.Entry{}
+	}
+	return file.Messages()
+}
+
+// diffMessages compares the messages by key. A message changes when its
+// plural key does, or its translations when translations is set.
+func diffMessages(before map[string]*po.Entry, after map[string]*po.Entry, translations bool) messageDiff {
+	var diff messageDiff
+
+	for key, entry := range after {
+		previous, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, entry.Label())
+		case previous.IDPlural != entry.IDPlural:
+			diff.Changed = append(diff.Changed, entry.Label())
+		case translations && !sameStrings(previous.Str, entry.Str):
+			diff.Changed = append(diff.Changed, entry.Label())
+		}
+	}
+	for key, entry := range before {
+		if _, ok := after[key]; !ok {
+			diff.Removed = append(diff.Removed, entry.Label())
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printDiffs writes the diffs with a line per key, prefixed with +, - or ~.
+func printDiffs(w io.Writer, diffs []messageDiff) {
+	changes := false
+	for _, diff := range diffs {
+		if diff.Empty() {
+			continue
+		}
+		changes = true
+		if diff.Locale != "" {
+			fmt.Fprintf(w, "[%s]\n", diff.Locale)
+		}
+		for _, key := range diff.Added {
+			fmt.Fprintf(w, "+ %q\n", key)
+		}
+		for _, key := range diff.Removed {
+			fmt.Fprintf(w, "- %q\n", key)
+		}
+		for _, key := range diff.Changed {
+			fmt.Fprintf(w, "~ %q\n", key)
+		}
+	}
+	if !changes {
+		fmt.Fprintln(w, "no changes")
+	}
+}
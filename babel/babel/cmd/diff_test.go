@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffBundles(t *testing.T) {
+	before := map[string]*po.File{
+		"es-AR": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+
+msgid "Sell"
+msgstr "Vender"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d ítem"
+msgstr[1] "%d ítems"
+`),
+		"pt-BR": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+`),
+	}
+	after := map[string]*po.File{
+		"es-AR": parsePo(t, `
+msgid "Buy"
+msgstr "Compralo"
+
+msgctxt "door"
+msgid "Open"
+msgstr "Abrir"
+
+msgid "%d item"
+msgid_plural "%d things"
+msgstr[0] "%d ítem"
+msgstr[1] "%d ítems"
+`),
+		"pt-BR": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+`),
+		"es-MX": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+`),
+	}
+
+	diffs := diffBundles(before, after)
+	require.Equal(t, []messageDiff{
+		{Locale: "es-AR", Added: []string{"Open [door]"}, Removed: []string{"Sell"}, Changed: []string{"%d item", "Buy"}},
+		{Locale: "es-MX", Added: []string{"Buy"}},
+	}, diffs)
+
+	output := bytes.NewBuffer(nil)
+	printDiffs(output, diffs)
+	require.Equal(t, `[es-AR]
++ "Open [door]"
+- "Sell"
+~ "%d item"
+~ "Buy"
+[es-MX]
++ "Buy"
+`, output.String())
+}
+
+func TestDiffSourceAgainstBundle(t *testing.T) {
+	source := map[string]*po.File{"": parsePo(t, `
+msgid "Buy"
+msgstr "Buy"
+
+msgid "Sell"
+msgstr "Sell"
+`)}
+	bundle := map[string]*po.File{"es-AR": parsePo(t, `
+msgid "Buy"
+msgstr "Comprar"
+`)}
+
+	require.Equal(t, []messageDiff{{Locale: "es-AR", Removed: []string{"Sell"}}}, diffBundles(source, bundle))
+
+	output := bytes.NewBuffer(nil)
+	printDiffs(output, []messageDiff{diffMessages(remoteMessages(bundle), bundle["es-AR"].Messages(), false)})
+	require.Equal(t, "no changes\n", output.String())
+}
@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mercadolibre/coreservices-team/babel"
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(lintCommand)
+}
+
+// lintIssue is a problem found in a translation of the bundle.
+type lintIssue struct {
+	File    string
+	Locale  string
+	Line    int
+	Key     string
+	Message string
+}
+
+func (i lintIssue) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %q: %s", i.File, i.Line, i.Locale, i.Key, i.Message)
+}
+
+var lintCommand = &cobra.Command{
+	Use:   "lint",
+	Short: "Validate the message bundle.",
+	Long:  "Check that the translations of the message bundle keep the format verbs, HTML tags and braces of their keys, and that the translations of ICU keys are valid messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		var issues []lintIssue
+
+		err := po.WalkBundle(flag(cmd, "bundle"), func(name string, locale string, file *po.File) error {
+			issues = append(issues, lint(name, locale, file)...)
+			return nil
+		})
+		assert(err, "failed to read the messages bundle")
+
+		printIssues(os.Stdout, issues)
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func printIssues(w io.Writer, issues []lintIssue) {
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+	}
+}
+
+// lint checks every translated message of the file against its keys.
+func lint(name string, locale string, file *po.File) []lintIssue {
+	var issues []lintIssue
+
+	for _, entry := range file.Entries {
+		if entry.Obsolete || entry.IsHeader() {
+			continue
+		}
+
+		report := func(message string) {
+			issues = append(issues, lintIssue{
+				File:    name,
+				Locale:  locale,
+				Line:    entry.Line,
+				Key:     entry.Label(),
+				Message: message,
+			})
+		}
+
+		for i, str := range entry.Str {
+			if str == "" {
+				continue
+			}
+
+			form := "msgstr"
+			if entry.IsPlural() {
+				form = fmt.Sprintf("msgstr[%d]", i)
+			}
+
+			if message, ok := checkVerbs(entry, str); !ok {
+				report(form + " " + message)
+			}
+			if message, ok := checkTags(entry.ID, str); !ok {
+				report(form + " " + message)
+			}
+			if balanced(entry.ID) && !balanced(str) {
+				report(form + " has unbalanced braces")
+			} else if err := babel.ValidateMessage(entry.ID, str); err != nil {
+				report(fmt.Sprintf("%s is not a valid ICU message: %v", form, err))
+			}
+		}
+	}
+
+	return issues
+}
+
+// verb is a printf verb along with the argument it formats.
+type verb struct {
+	arg  int
+	verb byte
+}
+
+func (v verb) String() string {
+	return fmt.Sprintf("%%[%d]%c", v.arg, v.verb)
+}
+
+// printfVerbs returns the verbs of a fmt format string, resolving the
+// argument each one formats, explicit indexes included. A * width or
+// precision takes an argument too, and is reported as a verb of its own.
+func printfVerbs(format string) []verb {
+	var verbs []verb
+	arg := 1
+
+	index := func(i int) int {
+		// explicit argument index, %[2]d
+		if i < len(format) && format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				n := 0
+				if _, err := fmt.Sscanf(format[i+1:i+end], "%d", &n); err == nil {
+					arg = n
+				}
+				return i + end + 1
+			}
+		}
+		return i
+	}
+	star := func(i int) int {
+		if i < len(format) && format[i] == '*' {
+			verbs = append(verbs, verb{arg: arg, verb: '*'})
+			arg++
+			return i + 1
+		}
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		i = star(index(i))
+		if i < len(format) && format[i] == '.' {
+			i = star(index(i + 1))
+		}
+		i = index(i)
+
+		if i >= len(format) {
+			break
+		}
+		if format[i] == '%' {
+			continue
+		}
+		verbs = append(verbs, verb{arg: arg, verb: format[i]})
+		arg++
+	}
+
+	return verbs
+}
+
+// checkVerbs compares the verbs of a translation with the ones of its msgid,
+// or of its msgid_plural for plural messages.
+func checkVerbs(entry *po.Entry, str string) (string, bool) {
+	translated := printfVerbs(str)
+
+	expected := printfVerbs(entry.ID)
+	if sameVerbs(expected, translated) {
+		return "", true
+	}
+	if entry.IsPlural() {
+		plural := printfVerbs(entry.IDPlural)
+		if sameVerbs(plural, translated) {
+			return "", true
+		}
+		if len(plural) > len(expected) {
+			expected = plural
+		}
+	}
+
+	if sameVerbs(sortedVerbs(expected), sortedVerbs(translated)) {
+		return fmt.Sprintf("formats its arguments in a different order (%s instead of %s), use explicit indexes like %%[2]s",
+			verbList(translated), verbList(expected)), false
+	}
+	return fmt.Sprintf("has the format verbs %s instead of %s", verbList(translated), verbList(expected)), false
+}
+
+// sameVerbs reports whether both formats use every argument with the same
+// verb, in any order.
+func sameVerbs(a []verb, b []verb) bool {
+	index := func(verbs []verb) map[verb]int {
+		result := map[verb]int{}
+		for _, v := range verbs {
+			result[v]++
+		}
+		return result
+	}
+
+	x, y := index(a), index(b)
+	if len(x) != len(y) {
+		return false
+	}
+	for v, n := range x {
+		if y[v] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedVerbs drops the argument positions, keeping the verbs only, so that
+// formats using the same verbs in another order compare equal.
+func sortedVerbs(verbs []verb) []verb {
+	result := make([]verb, len(verbs))
+	for i, v := range verbs {
+		result[i] = verb{verb: v.verb}
+	}
+	return result
+}
+
+func verbList(verbs []verb) string {
+	if len(verbs) == 0 {
+		return "none"
+	}
+	list := make([]string, len(verbs))
+	for i, v := range verbs {
+		list[i] = "%" + string(v.verb)
+	}
+	return strings.Join(list, " ")
+}
+
+var htmlTag = regexp.MustCompile(`<(/?[a-zA-Z][a-zA-Z0-9-]*)[^<>]*>`)
+
+// checkTags compares the HTML tags of a translation with the ones of its key.
+func checkTags(id string, str string) (string, bool) {
+	count := func(text string) map[string]int {
+		tags := map[string]int{}
+		for _, match := range htmlTag.FindAllStringSubmatch(text, -1) {
+			tags[strings.ToLower(match[1])]++
+		}
+		return tags
+	}
+
+	expected, found := count(id), count(str)
+	var stray, missing []string
+	for tag, n := range found {
+		if n > expected[tag] {
+			stray = append(stray, "<"+tag+">")
+		}
+	}
+	for tag, n := range expected {
+		if n > found[tag] {
+			missing = append(missing, "<"+tag+">")
+		}
+	}
+	sort.Strings(stray)
+	sort.Strings(missing)
+
+	switch {
+	case len(stray) > 0 && len(missing) > 0:
+		return fmt.Sprintf("has the stray HTML tags %s and lacks %s", strings.Join(stray, " "), strings.Join(missing, " ")), false
+	case len(stray) > 0:
+		return fmt.Sprintf("has the stray HTML tags %s", strings.Join(stray, " ")), false
+	case len(missing) > 0:
+		return fmt.Sprintf("lacks the HTML tags %s", strings.Join(missing, " ")), false
+	}
+	return "", true
+}
+
+// balanced reports whether every brace of the text is closed, in order.
+func balanced(text string) bool {
+	depth := 0
+	for _, c := range text {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
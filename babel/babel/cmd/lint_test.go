@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintfVerbs(t *testing.T) {
+	tt := []struct {
+		Format   string
+		Expected []verb
+	}{
+		{"no verbs, 100%%", nil},
+		{"%d items for %s", []verb{{1, 'd'}, {2, 's'}}},
+		{"%-10.2f %v", []verb{{1, 'f'}, {2, 'v'}}},
+		{"%[2]s then %[1]d", []verb{{2, 's'}, {1, 'd'}}},
+		{"%*d", []verb{{1, '*'}, {2, 'd'}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Format, func(t *testing.T) {
+			require.Equal(t, tc.Expected, printfVerbs(tc.Format))
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	file := parsePo(t, `
+msgid "%d items for %s"
+msgstr "%d ítems para %s"
+
+msgid "Hello %s"
+msgstr "Hola"
+
+msgid "Name: %s"
+msgstr "Nombre: %v"
+
+msgid "%d of %s"
+msgstr "%s: %d"
+
+msgid "%d of %s"
+msgstr "%[2]s: %[1]d"
+
+msgid "Click <b>here</b>"
+msgstr "Hacé <i>click</i> <b>acá</b>"
+
+msgid "Hi {name}"
+msgstr "Hola {name"
+
+msgid "{count, plural, one {# item} other {# items}}"
+msgstr "{count, plural, one {# ítem} otro {# ítems}}"
+
+msgid "One item"
+msgid_plural "%d items"
+msgstr[0] "Un ítem"
+msgstr[1] "%d ítems"
+msgstr[2] ""
+`)
+
+	issues := lint("es-AR/messages.po", "es-AR", file)
+
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+	require.Equal(t, []string{
+		`es-AR/messages.po:5: [es-AR] "Hello %s": msgstr has the format verbs none instead of %s`,
+		`es-AR/messages.po:8: [es-AR] "Name: %s": msgstr has the format verbs %v instead of %s`,
+		`es-AR/messages.po:11: [es-AR] "%d of %s": msgstr formats its arguments in a different order (%s %d instead of %d %s), use explicit indexes like %[2]s`,
+		`es-AR/messages.po:17: [es-AR] "Click <b>here</b>": msgstr has the stray HTML tags </i> <i>`,
+		`es-AR/messages.po:20: [es-AR] "Hi {name}": msgstr has unbalanced braces`,
+		`es-AR/messages.po:23: [es-AR] "{count, plural, one {# item} other {# items}}": msgstr is not a valid ICU message: offset 33: unknown plural category otro`,
+	}, messages)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	RootCmd.AddCommand(pseudoCommand)
+
+	pseudoCommand.Flags().String("locale", "en-XA", "pseudo-locale to generate")
+	pseudoCommand.Flags().String("output", "./conf/i18n", "bundle directory the pseudo-locale is written to")
+	pseudoCommand.Flags().Float64("expansion", 0.3, "fraction of the text length added as padding")
+	pseudoCommand.Flags().Bool("brackets", true, "wrap the messages in brackets")
+	viper.BindPFlag("locale", pseudoCommand.Flags().Lookup("locale"))
+	viper.BindPFlag("output", pseudoCommand.Flags().Lookup("output"))
+	viper.BindPFlag("expansion", pseudoCommand.Flags().Lookup("expansion"))
+	viper.BindPFlag("brackets", pseudoCommand.Flags().Lookup("brackets"))
+}
+
+var pseudoCommand = &cobra.Command{
+	Use:   "pseudo",
+	Short: "Generate a pseudo-localized bundle.",
+	Long:  "Generate a pseudo-locale from the messages file, with accented, padded and bracketed translations that reveal hardcoded and truncated strings",
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := po.ParseFile(flag(cmd, "messages"))
+		assert(err, "failed to read the messages file")
+
+		locale := flag(cmd, "locale")
+		options := pseudoOptions{expansion: viper.GetFloat64("expansion"), brackets: viper.GetBool("brackets")}
+		file := pseudoLocalize(source, locale, options)
+
+		dir := filepath.Join(flag(cmd, "output"), locale)
+		assert(os.MkdirAll(dir, 0777), "failed to create the pseudo-locale directory")
+
+		output, err := os.Create(filepath.Join(dir, "messages.po"))
+		assert(err, "failed to create the pseudo-locale file")
+		defer output.Close()
+
+		assert(file.Write(output), "failed to write the pseudo-locale file")
+	},
+}
+
+type pseudoOptions struct {
+	expansion float64
+	brackets  bool
+}
+
+// pseudoLocalize translates every message of the source with pseudo.
+func pseudoLocalize(source *po.File, locale string, options pseudoOptions) *po.File {
+	file := &po.File{Entries: []*po.Entry{{
+		Str: []string{fmt.Sprintf("Language: %s\nContent-Type: text/plain; charset=UTF-8\nPlural-Forms: nplurals=2; plural=(n != 1);\n", locale)},
+	}}}
+
+	for _, message := range source.Messages() {
+		entry := &po.Entry{
+			References: message.References,
+			Context:    message.Context,
+			ID:         message.ID,
+			IDPlural:   message.IDPlural,
+			Str:        []string{pseudo(message.ID, options)},
+		}
+		if message.IsPlural() {
+			entry.Str = append(entry.Str, pseudo(message.IDPlural, options))
+		}
+		file.Entries = append(file.Entries, entry)
+	}
+	file.Sort()
+	return file
+}
+
+// pseudoAccents maps ASCII letters to accented look-alikes.
+var pseudoAccents = map[rune]rune{
+	'a': 'á', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ', 'h': 'ĥ', 'i': 'î',
+	'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ', 'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ',
+	's': 'š', 't': 'ţ', 'u': 'û', 'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Ð', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ', 'H': 'Ĥ', 'I': 'Î',
+	'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ', 'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ',
+	'S': 'Š', 'T': 'Ţ', 'U': 'Û', 'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+// pseudoVerbatim matches the parts of a message kept as they are: printf
+// verbs and HTML tags.
+var pseudoVerbatim = regexp.MustCompile(`%[-+# 0]*(\[\d+\])?(\*|\d+)?(\.(\[\d+\])?(\*|\d+)?)?(\[\d+\])?[a-zA-Z%]|</?[a-zA-Z][^>]*>`)
+
+// pseudo accents the letters of the text, pads it by the expansion factor
+// and wraps it in brackets, leaving its verbs, tags and ICU arguments intact.
+func pseudo(text string, options pseudoOptions) string {
+	var w pseudoWriter
+
+	if options.brackets {
+		w.WriteString("[")
+	}
+	w.message(text, 0, false)
+
+	if padding := int(math.Ceil(float64(w.letters) * options.expansion)); padding > 0 {
+		w.WriteString(" ")
+		w.WriteString(strings.Repeat("~", padding))
+	}
+	if options.brackets {
+		w.WriteString("]")
+	}
+	return w.String()
+}
+
+// pseudoWriter writes a pseudo-localized text, counting the letters it
+// accents.
+type pseudoWriter struct {
+	strings.Builder
+	letters int
+}
+
+// accent writes the text with its letters accented, except for the verbatim
+// parts.
+func (w *pseudoWriter) accent(text string) {
+	last := 0
+	for _, match := range pseudoVerbatim.FindAllStringIndex(text, -1) {
+		w.accentLetters(text[last:match[0]])
+		w.WriteString(text[match[0]:match[1]])
+		last = match[1]
+	}
+	w.accentLetters(text[last:])
+}
+
+func (w *pseudoWriter) accentLetters(text string) {
+	for _, r := range text {
+		if accented, ok := pseudoAccents[r]; ok {
+			r = accented
+		}
+		w.WriteRune(r)
+	}
+	w.letters += utf8.RuneCountInString(text)
+}
+
+// message writes the text from i, keeping the ICU arguments as they are and
+// accenting the text of their cases. Within a case, nested, it stops at the
+// brace closing it and returns its index.
+func (w *pseudoWriter) message(text string, i int, nested bool) int {
+	start := i
+	for i < len(text) {
+		switch text[i] {
+		case '\'':
+			// a quoted brace or # is text, not syntax
+			if i+1 < len(text) && strings.IndexByte("{}#|", text[i+1]) >= 0 {
+				if end := strings.IndexByte(text[i+1:], '\''); end >= 0 {
+					i += end + 2
+					continue
+				}
+			}
+			i++
+		case '{':
+			w.accent(text[start:i])
+			i = w.argument(text, i)
+			start = i
+		case '}':
+			if nested {
+				w.accent(text[start:i])
+				return i
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	w.accent(text[start:])
+	return i
+}
+
+// argument writes the ICU argument starting at i as it is, except for the
+// text of its cases, and returns the index after it.
+func (w *pseudoWriter) argument(text string, i int) int {
+	for i < len(text) {
+		// the name, type, style, offset and selectors run up to a brace
+		end := strings.IndexAny(text[i+1:], "{}")
+		if end < 0 {
+			w.WriteString(text[i:])
+			return len(text)
+		}
+		end += i + 1
+		w.WriteString(text[i : end+1])
+		if text[end] == '}' {
+			return end + 1
+		}
+		i = w.message(text, end+1, true)
+	}
+	return i
+}
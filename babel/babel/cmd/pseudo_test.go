@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPseudo(t *testing.T) {
+	tests := []struct {
+		text     string
+		options  pseudoOptions
+		expected string
+	}{
+		{"Hello", pseudoOptions{}, "Ĥéļļö"},
+		{"Hello", pseudoOptions{brackets: true}, "[Ĥéļļö]"},
+		{"Hello", pseudoOptions{expansion: 0.3, brackets: true}, "[Ĥéļļö ~~]"},
+		{"%d items for %[2]s", pseudoOptions{}, "%d îţéɱš ƒöŕ %[2]s"},
+		{"100%% of %-5.2f", pseudoOptions{}, "100%% öƒ %-5.2f"},
+		{`<a href="/cart">Cart</a>`, pseudoOptions{}, `<a href="/cart">Çáŕţ</a>`},
+		{"Hi {name}, {count, number, integer}", pseudoOptions{}, "Ĥî {name}, {count, number, integer}"},
+		{"{count, plural, offset:1 =0 {none} one {# item} other {# items}}", pseudoOptions{},
+			"{count, plural, offset:1 =0 {ñöñé} one {# îţéɱ} other {# îţéɱš}}"},
+		{"{gender, select, female {{name} is here} other {They are}}", pseudoOptions{},
+			"{gender, select, female {{name} îš ĥéŕé} other {Ţĥéý áŕé}}"},
+		{"It''s '{'quoted'}'", pseudoOptions{}, "Îţ''š '{'ǫûöţéð'}'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			require.Equal(t, test.expected, pseudo(test.text, test.options))
+		})
+	}
+}
+
+func TestPseudoLocalize(t *testing.T) {
+	source := parsePo(t, `
+msgid ""
+msgstr "Language: en\n"
+
+msgctxt "door"
+msgid "Open"
+msgstr "Open"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] ""
+msgstr[1] ""
+`)
+
+	file := pseudoLocalize(source, "en-XA", pseudoOptions{brackets: true})
+	require.Len(t, file.Entries, 3)
+	require.Contains(t, file.Entries[0].Str[0], "Language: en-XA\n")
+
+	require.Equal(t, "%d item", file.Entries[1].ID)
+	require.Equal(t, []string{"[%d îţéɱ]", "[%d îţéɱš]"}, file.Entries[1].Str)
+	require.Equal(t, "door", file.Entries[2].Context)
+	require.Equal(t, []string{"[Öþéñ]"}, file.Entries[2].Str)
+
+	require.Empty(t, lint("en-XA/messages.po", "en-XA", file))
+}
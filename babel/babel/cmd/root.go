@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -27,6 +28,10 @@ func init() {
 	RootCmd.PersistentFlags().String("project", "", "Babel project name")
 	RootCmd.PersistentFlags().String("messages", "./conf/messages.po", "messages filename")
 	RootCmd.PersistentFlags().String("bundle", "./conf/all.zip", "message bundle with all translations")
+	RootCmd.PersistentFlags().String("url", "http://i18n.ml.com", "Babel API base URL")
+	RootCmd.PersistentFlags().String("apikey", "", "Babel API key")
+	RootCmd.PersistentFlags().Duration("timeout", 30*time.Second, "timeout of each request to the Babel API")
+	RootCmd.PersistentFlags().Int("retries", 3, "retries of the requests failing with network or server errors")
 
 	viper.BindPFlags(RootCmd.PersistentFlags())
 }
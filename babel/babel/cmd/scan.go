@@ -1,17 +1,29 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/mercadolibre/coreservices-team/babel/babel/scanner"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
 	RootCmd.AddCommand(scanCommand)
 
+	scanCommand.Flags().Bool("merge", false, "keep the comments of the existing messages file and mark removed keys as obsolete")
+	scanCommand.Flags().Bool("strict", false, "list the translation calls with dynamic keys and exit with an error if there are any")
+	scanCommand.Flags().StringSlice("templates", []string{"*.tmpl", "*.gohtml"}, "glob patterns of the template files to scan")
+	scanCommand.Flags().StringSlice("template-funcs", []string{"tr=Tr", "trn=Trn", "trc=Trc", "trnc=Trnc", "trf=Trf"}, "template functions that translate, and the babel function each one wraps")
+	scanCommand.Flags().StringSlice("methods", []string{"Tr=Tr", "Trn=Trn", "Trc=Trc", "Trnc=Trnc", "Trf=Trf"}, "methods of the gk.Context that translate, and the babel function each one wraps")
+	viper.BindPFlag("merge", scanCommand.Flags().Lookup("merge"))
+	viper.BindPFlag("strict", scanCommand.Flags().Lookup("strict"))
+	viper.BindPFlag("templates", scanCommand.Flags().Lookup("templates"))
+	viper.BindPFlag("template-funcs", scanCommand.Flags().Lookup("template-funcs"))
+	viper.BindPFlag("methods", scanCommand.Flags().Lookup("methods"))
 }
 
 var scanCommand = &cobra.Command{
@@ -21,14 +33,71 @@ var scanCommand = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		scanner := scanner.NewFileScanner()
 
-		err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-			if !info.IsDir() && strings.HasSuffix(path, ".go") && !strings.Contains(path, "vendor/") {
+		funcs, err := funcPairs(viper.GetStringSlice("template-funcs"))
+		assert(err, "invalid template functions")
+		templates := viper.GetStringSlice("templates")
+		methods, err := funcPairs(viper.GetStringSlice("methods"))
+		assert(err, "invalid methods")
+		scanner.SetMethods(methods)
+
+		err = filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+			if info.IsDir() || strings.Contains(path, "vendor/") {
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") {
 				return scanner.Scan(path)
 			}
+			if matchAny(templates, path) {
+				return scanner.ScanTemplate(path, funcs)
+			}
 			return nil
 		})
 
 		assert(err, "filed to scan the project files")
-		scanner.Save(flag(cmd, "messages"))
+
+		for _, diagnostic := range scanner.Diagnostics() {
+			fmt.Fprintln(os.Stderr, diagnostic)
+		}
+		if viper.GetBool("merge") {
+			err = scanner.Merge(flag(cmd, "messages"))
+		} else {
+			err = scanner.Save(flag(cmd, "messages"))
+		}
+		assert(err, "failed to write the messages file")
+
+		if viper.GetBool("strict") && len(scanner.DynamicKeys()) > 0 {
+			for _, diagnostic := range scanner.DynamicKeys() {
+				fmt.Fprintln(os.Stderr, diagnostic)
+			}
+			os.Exit(1)
+		}
 	},
 }
+
+// funcPairs parses the name=Func pairs of the --template-funcs and --methods
+// flags.
+func funcPairs(pairs []string) (map[string]string, error) {
+	funcs := map[string]string{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("%s is not a name=Func pair", pair)
+		}
+		funcs[parts[0]] = parts[1]
+	}
+	return funcs, nil
+}
+
+// matchAny reports whether the path, or its base name, matches any of the
+// glob patterns.
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -6,23 +6,30 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
-	"net/http"
 	"os"
 
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
 	RootCmd.AddCommand(uploadCommand)
-}
 
-const postURL = "http://i18n.ml.com/apps/%s/sources?project_name=%s&force=false"
+	uploadCommand.Flags().Bool("dry-run", false, "print the keys the upload would add, remove or change instead of uploading")
+	viper.BindPFlag("dry-run", uploadCommand.Flags().Lookup("dry-run"))
+}
 
 var uploadCommand = &cobra.Command{
 	Use:   "upload",
 	Short: "Upload the message files",
 	Long:  "Upload the message files to Babel",
 	Run: func(cmd *cobra.Command, args []string) {
+		if viper.GetBool("dry-run") {
+			dryRun(cmd)
+			return
+		}
+
 		body := bytes.NewBuffer(nil)
 
 		bodyWriter := multipart.NewWriter(body)
@@ -39,16 +46,42 @@ var uploadCommand = &cobra.Command{
 		file, err := zipfile.Create("messages.po")
 		assert(err, "failed to create the zipped source file")
 
-		io.Copy(file, source)
+		_, err = io.Copy(file, source)
+		assert(err, "failed to zip the source file")
 
 		zipfile.Close()
 		bodyWriter.Close()
 
-		url := fmt.Sprintf(postURL, flag(cmd, "app"), flag(cmd, "project"))
-		resp, err := http.Post(url, bodyWriter.FormDataContentType(), body)
+		resp, err := clientFromConfig().Upload(flag(cmd, "app"), flag(cmd, "project"), bodyWriter.FormDataContentType(), body.Bytes())
 		assert(err, "failed to post to Babel")
-		defer resp.Body.Close()
 
-		io.Copy(os.Stdout, resp.Body)
+		fmt.Println(string(resp))
 	},
 }
+
+// dryRun prints the keys of the messages file that would be added, removed or
+// changed in the remote bundle.
+func dryRun(cmd *cobra.Command) {
+	source, err := po.ParseFile(flag(cmd, "messages"))
+	assert(err, "failed to read the messages file")
+
+	bundle, err := clientFromConfig().Download(flag(cmd, "app"))
+	assert(err, "failed to get the messages bundle")
+
+	remote, err := po.ReadZip(bytes.NewReader(bundle), int64(len(bundle)))
+	assert(err, "failed to read the messages bundle")
+
+	printDiffs(os.Stdout, []messageDiff{diffMessages(remoteMessages(remote), source.Messages(), false)})
+}
+
+// remoteMessages joins the messages of every locale of the bundle, since a
+// key is uploaded once for all of them.
+func remoteMessages(bundle map[string]*po.File) map[string]*po.Entry {
+	messages := map[string]*po.Entry{}
+	for _, file := range bundle {
+		for key, entry := range file.Messages() {
+			messages[key] = entry
+		}
+	}
+	return messages
+}
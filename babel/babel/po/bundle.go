@@ -0,0 +1,129 @@
+package po
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ReadBundle reads every .po, .mo and .json file of a .zip bundle or a bundle
+// directory. The files are indexed by locale, the name of the directory
+// holding them, and the files of the same locale are joined.
+func ReadBundle(bundle string) (map[string]*File, error) {
+	files := map[string]*File{}
+	if err := WalkBundle(bundle, joinLocales(files)); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ReadZip is ReadBundle for a .zip bundle of the given size held in memory.
+func ReadZip(bundle io.ReaderAt, size int64) (map[string]*File, error) {
+	reader, err := zip.NewReader(bundle, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*File{}
+	if err := walkZip(reader, joinLocales(files)); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// joinLocales returns a WalkBundle callback that adds the files to the map,
+// joining the files of the same locale.
+func joinLocales(files map[string]*File) func(name string, locale string, file *File) error {
+	return func(name string, locale string, file *File) error {
+		if existing, ok := files[locale]; ok {
+			existing.Entries = append(existing.Entries, file.Entries...)
+			return nil
+		}
+		files[locale] = file
+		return nil
+	}
+}
+
+// WalkBundle reads every .po, .mo and .json file of a .zip bundle or a bundle
+// directory and calls fn with the file name, its locale and its content.
+func WalkBundle(bundle string, fn func(name string, locale string, file *File) error) error {
+	info, err := os.Stat(bundle)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return filepath.Walk(bundle, func(fullpath string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() || !IsBundleFile(fullpath) {
+				return err
+			}
+			file, err := ReadFile(fullpath)
+			if err != nil {
+				return err
+			}
+			return fn(fullpath, filepath.Base(filepath.Dir(fullpath)), file)
+		})
+	}
+
+	reader, err := zip.OpenReader(bundle)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return walkZip(&reader.Reader, fn)
+}
+
+func walkZip(reader *zip.Reader, fn func(name string, locale string, file *File) error) error {
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() || !IsBundleFile(entry.Name) {
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		file, err := Decode(entry.Name, content)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry.Name, path.Base(path.Dir(entry.Name)), file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadFile reads the .po, .mo or .json file with the given name.
+func ReadFile(filename string) (*File, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(filename, content)
+}
+
+// ParseFile reads the .po file with the given name.
+func ParseFile(filename string) (*File, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	file, err := Parse(fd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return file, nil
+}
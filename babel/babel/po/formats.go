@@ -0,0 +1,28 @@
+package po
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mercadolibre/coreservices-team/babel"
+)
+
+// IsBundleFile reports whether the file is a .po, .mo or .json messages file.
+func IsBundleFile(name string) bool {
+	return babel.IsBundleFile(name)
+}
+
+// Decode reads the content of the named .po, .mo or .json messages file. The
+// .mo and .json files are converted by the babel library, so they read the
+// same here as in the translators.
+func Decode(name string, content []byte) (*File, error) {
+	content, err := babel.DecodeFile(name, content)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return file, nil
+}
@@ -0,0 +1,78 @@
+package po
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBundleFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{
+		// the files of a locale are joined
+		"es/backend.po": []byte(`msgid ""
+msgstr "Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+`),
+		"es/frontend.json": []byte(`{
+	"door\u0004Open": "Abrir",
+	"%d item": ["%d artículo", "%d artículos"]
+}`),
+		"pt-BR/messages.json": []byte(`{
+	"Hello": "Olá",
+	"door\u0004Open": "Abrir",
+	"%d item": ["%d item", "%d itens"]
+}`),
+		"en/messages.po": []byte(`msgid "Hello"
+msgstr "Hi"
+`),
+		"en/notes.txt": []byte("not a messages file"),
+	}
+	for name, content := range files {
+		fullpath := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullpath), 0777))
+		require.NoError(t, ioutil.WriteFile(fullpath, content, 0666))
+	}
+
+	bundle, err := ReadBundle(dir)
+	require.NoError(t, err)
+	require.Len(t, bundle, 3)
+
+	assert.Equal(t, "Hi", bundle["en"].Messages()["Hello"].Str[0])
+
+	for _, locale := range []string{"es", "pt-BR"} {
+		messages := bundle[locale].Messages()
+		assert.Len(t, messages, 3, locale)
+		assert.Equal(t, []string{"Abrir"}, messages[Key("door", "Open")].Str, locale)
+	}
+
+	es := bundle["es"].Messages()
+	assert.Equal(t, "Language: es\n", bundle["es"].Header().Str[0])
+	assert.Equal(t, []string{"Hola"}, es["Hello"].Str)
+	assert.Equal(t, []string{"%d artículo", "%d artículos"}, es["%d item"].Str)
+
+	pt := bundle["pt-BR"].Messages()
+	assert.Equal(t, []string{"Olá"}, pt["Hello"].Str)
+	assert.Equal(t, "%d item", pt["%d item"].IDPlural)
+	assert.Equal(t, []string{"%d item", "%d itens"}, pt["%d item"].Str)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := Decode("messages.mo", []byte("not a compiled messages file"))
+	assert.EqualError(t, err, "messages.mo: invalid .mo file: bad magic number")
+
+	_, err = Decode("messages.json", []byte(`{"Hello": 1}`))
+	assert.EqualError(t, err, `messages.json: "Hello": translations must be a string or an array of strings`)
+
+	_, err = Decode("messages.txt", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,295 @@
+// Package po reads and writes gettext .po files, keeping the comments, flags
+// and obsolete entries that the runtime loader discards.
+package po
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Entry is a message of a .po file along with its comments.
+type Entry struct {
+	// Comments are the translator comments ("# ...").
+	Comments []string
+	// Extracted are the comments extracted from the code ("#. ...").
+	Extracted []string
+	// References are the source positions of the message ("#: file:line").
+	References []string
+	// Flags are the message flags ("#, fuzzy, c-format").
+	Flags []string
+	// Previous are the previous msgid lines of a fuzzy message ("#| ...").
+	Previous []string
+
+	Context  string
+	ID       string
+	IDPlural string
+	// Str holds the msgstr, or every msgstr[n] of a plural message.
+	Str []string
+
+	// Obsolete entries are commented out with "#~".
+	Obsolete bool
+
+	// Line is the line the entry starts at in the parsed file.
+	Line int
+}
+
+// Key identifies the entry within its file, taking the context into account.
+func (e *Entry) Key() string {
+	return Key(e.Context, e.ID)
+}
+
+// Key returns the identifier of a message within a file, joining the context
+// and the msgid the same way .mo files do.
+func Key(context string, id string) string {
+	if context == "" {
+		return id
+	}
+	return context + "\x04" + id
+}
+
+// Label returns a readable name for the entry, used in reports.
+func (e *Entry) Label() string {
+	if e.Context == "" {
+		return e.ID
+	}
+	return fmt.Sprintf("%s [%s]", e.ID, e.Context)
+}
+
+// IsTranslated reports whether the entry has a non empty msgstr for every
+// form.
+func (e *Entry) IsTranslated() bool {
+	if len(e.Str) == 0 {
+		return false
+	}
+	for _, str := range e.Str {
+		if str == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// IsHeader reports whether the entry is the file header (the empty msgid).
+func (e *Entry) IsHeader() bool {
+	return e.ID == "" && e.Context == ""
+}
+
+// IsPlural reports whether the entry has plural forms.
+func (e *Entry) IsPlural() bool {
+	return e.IDPlural != ""
+}
+
+// HasFlag reports whether the entry is marked with the given flag.
+func (e *Entry) HasFlag(flag string) bool {
+	for _, f := range e.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// File is a parsed .po file.
+type File struct {
+	Entries []*Entry
+}
+
+// Header returns the header entry of the file, if any.
+func (f *File) Header() *Entry {
+	for _, entry := range f.Entries {
+		if entry.IsHeader() && !entry.Obsolete {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Messages returns the non obsolete entries of the file, header excluded,
+// indexed by key.
+func (f *File) Messages() map[string]*Entry {
+	messages := make(map[string]*Entry, len(f.Entries))
+	for _, entry := range f.Entries {
+		if !entry.Obsolete && !entry.IsHeader() {
+			messages[entry.Key()] = entry
+		}
+	}
+	return messages
+}
+
+// Sort orders the entries by msgid and context, keeping the header first and
+// the obsolete entries last, so that the written file is stable.
+func (f *File) Sort() {
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		a, b := f.Entries[i], f.Entries[j]
+		if a.Obsolete != b.Obsolete {
+			return !a.Obsolete
+		}
+		if a.IsHeader() != b.IsHeader() {
+			return a.IsHeader()
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Context < b.Context
+	})
+}
+
+type field int
+
+const (
+	fieldNone field = iota
+	fieldContext
+	fieldID
+	fieldIDPlural
+	fieldStr
+)
+
+// Parse reads a .po file.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	entry := &Entry{}
+	started := false
+	current := fieldNone
+	index := 0
+	number := 0
+
+	flush := func() {
+		if started {
+			file.Entries = append(file.Entries, entry)
+		}
+		entry = &Entry{Line: number}
+		started = false
+		current = fieldNone
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			flush()
+			continue
+		}
+		if !started {
+			entry.Line = number
+		}
+
+		obsolete := strings.HasPrefix(line, "#~")
+		if obsolete {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "#~"))
+			// a new obsolete message starts right after an active one
+			if started && !entry.Obsolete && current != fieldNone {
+				flush()
+			}
+			entry.Obsolete = true
+			started = true
+			if strings.HasPrefix(line, "|") {
+				entry.Previous = append(entry.Previous, strings.TrimSpace(line[1:]))
+				continue
+			}
+		}
+
+		if strings.HasPrefix(line, "#") {
+			// comments after the message strings belong to the next entry
+			if current != fieldNone {
+				flush()
+			}
+			started = true
+			parseComment(entry, line)
+			continue
+		}
+
+		keyword, value := line, ""
+		if i := strings.Index(line, " "); i >= 0 && !strings.HasPrefix(line, `"`) {
+			keyword, value = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		if strings.HasPrefix(line, `"`) {
+			text, err := unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", number, err)
+			}
+			switch current {
+			case fieldContext:
+				entry.Context += text
+			case fieldID:
+				entry.ID += text
+			case fieldIDPlural:
+				entry.IDPlural += text
+			case fieldStr:
+				entry.Str[index] += text
+			default:
+				return nil, fmt.Errorf("line %d: unexpected string", number)
+			}
+			continue
+		}
+
+		text, err := unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			if current != fieldNone {
+				flush()
+			}
+			current, entry.Context = fieldContext, text
+		case keyword == "msgid":
+			if current != fieldNone && current != fieldContext {
+				flush()
+			}
+			current, entry.ID = fieldID, text
+		case keyword == "msgid_plural":
+			current, entry.IDPlural = fieldIDPlural, text
+		case keyword == "msgstr":
+			current, index = fieldStr, len(entry.Str)
+			entry.Str = append(entry.Str, text)
+		case strings.HasPrefix(keyword, "msgstr["):
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("line %d: invalid plural index %s", number, keyword)
+			}
+			for len(entry.Str) <= n {
+				entry.Str = append(entry.Str, "")
+			}
+			current, index = fieldStr, n
+			entry.Str[n] = text
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %s", number, keyword)
+		}
+		entry.Obsolete = entry.Obsolete || obsolete
+		started = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return file, nil
+}
+
+func parseComment(entry *Entry, line string) {
+	switch {
+	case strings.HasPrefix(line, "#."):
+		entry.Extracted = append(entry.Extracted, strings.TrimSpace(line[2:]))
+	case strings.HasPrefix(line, "#:"):
+		entry.References = append(entry.References, strings.Fields(line[2:])...)
+	case strings.HasPrefix(line, "#,"):
+		for _, flag := range strings.Split(line[2:], ",") {
+			if flag = strings.TrimSpace(flag); flag != "" {
+				entry.Flags = append(entry.Flags, flag)
+			}
+		}
+	case strings.HasPrefix(line, "#|"):
+		entry.Previous = append(entry.Previous, strings.TrimSpace(line[2:]))
+	default:
+		entry.Comments = append(entry.Comments, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+	}
+}
@@ -0,0 +1,95 @@
+package po
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `msgid ""
+msgstr ""
+"Language: es\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# checkout button
+#. shown in the cart
+#: cart.go:10 cart.go:20
+#, fuzzy, c-format
+#| msgid "Buy %s"
+msgid "Buy %s now"
+msgstr "Comprar %s ya"
+
+msgctxt "door"
+msgid "Open"
+msgstr "Abrir"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d ítem"
+msgstr[1] "%d ítems"
+
+msgid "Multi"
+msgstr ""
+"first line\n"
+"second \"line\""
+
+#~ msgid "Gone"
+#~ msgstr "Ido"
+`
+
+func TestParse(t *testing.T) {
+	file, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+	require.Len(t, file.Entries, 6)
+
+	header := file.Header()
+	require.NotNil(t, header)
+	assert.Contains(t, header.Str[0], "Plural-Forms")
+
+	buy := file.Entries[1]
+	assert.Equal(t, 6, buy.Line)
+	assert.Equal(t, []string{"checkout button"}, buy.Comments)
+	assert.Equal(t, []string{"shown in the cart"}, buy.Extracted)
+	assert.Equal(t, []string{"cart.go:10", "cart.go:20"}, buy.References)
+	assert.Equal(t, []string{"fuzzy", "c-format"}, buy.Flags)
+	assert.True(t, buy.HasFlag("fuzzy"))
+	assert.Equal(t, []string{`msgid "Buy %s"`}, buy.Previous)
+	assert.Equal(t, "Comprar %s ya", buy.Str[0])
+
+	assert.Equal(t, "door\x04Open", file.Entries[2].Key())
+	assert.Equal(t, []string{"%d ítem", "%d ítems"}, file.Entries[3].Str)
+	assert.Equal(t, "first line\nsecond \"line\"", file.Entries[4].Str[0])
+
+	assert.True(t, file.Entries[5].Obsolete)
+	assert.Equal(t, "Gone", file.Entries[5].ID)
+	assert.Len(t, file.Messages(), 4)
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	file, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, file.Write(&out))
+	assert.Equal(t, sample, out.String())
+}
+
+func TestSort(t *testing.T) {
+	file := &File{Entries: []*Entry{
+		{ID: "b", Obsolete: true},
+		{ID: "c"},
+		{ID: "a", Context: "z"},
+		{ID: "a"},
+		{ID: "", Str: []string{"Language: es\n"}},
+	}}
+	file.Sort()
+
+	var keys []string
+	for _, entry := range file.Entries {
+		keys = append(keys, entry.Key())
+	}
+	assert.Equal(t, []string{"", "a", "z\x04a", "c", "b"}, keys)
+}
@@ -0,0 +1,60 @@
+package po
+
+import (
+	"fmt"
+	"strings"
+)
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\t", `\t`,
+	"\r", `\r`,
+)
+
+// quote returns the value as a .po string literal. Unlike strconv.Quote it
+// leaves non ASCII characters as they are.
+func quote(value string) string {
+	return `"` + escaper.Replace(value) + `"`
+}
+
+// unquote returns the value of a .po string literal.
+func unquote(literal string) (string, error) {
+	if len(literal) < 2 || literal[0] != '"' || literal[len(literal)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", literal)
+	}
+	literal = literal[1 : len(literal)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(literal); i++ {
+		c := literal[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if i++; i == len(literal) {
+			return "", fmt.Errorf("invalid escape at the end of %q", literal)
+		}
+		switch literal[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			// \\, \" and any unknown escape stand for the character itself
+			b.WriteByte(literal[i])
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,107 @@
+package po
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// referencesWidth is the line length references are wrapped at.
+const referencesWidth = 79
+
+// Write writes the file in .po format, entries in their current order.
+func (f *File) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	for i, entry := range f.Entries {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		entry.write(out)
+	}
+
+	return out.Flush()
+}
+
+func (e *Entry) write(out *bufio.Writer) {
+	prefix := ""
+	if e.Obsolete {
+		prefix = "#~ "
+	}
+
+	for _, comment := range e.Comments {
+		if comment == "" {
+			out.WriteString("#\n")
+			continue
+		}
+		fmt.Fprintf(out, "# %s\n", comment)
+	}
+	for _, comment := range e.Extracted {
+		fmt.Fprintf(out, "#. %s\n", comment)
+	}
+	writeReferences(out, e.References)
+	if len(e.Flags) > 0 {
+		fmt.Fprintf(out, "#, %s\n", strings.Join(e.Flags, ", "))
+	}
+	for _, previous := range e.Previous {
+		if e.Obsolete {
+			fmt.Fprintf(out, "#~| %s\n", previous)
+			continue
+		}
+		fmt.Fprintf(out, "#| %s\n", previous)
+	}
+
+	if e.Context != "" {
+		writeString(out, prefix, "msgctxt", e.Context)
+	}
+	writeString(out, prefix, "msgid", e.ID)
+	if e.IsPlural() {
+		writeString(out, prefix, "msgid_plural", e.IDPlural)
+		for i, str := range e.Str {
+			writeString(out, prefix, fmt.Sprintf("msgstr[%d]", i), str)
+		}
+		return
+	}
+
+	str := ""
+	if len(e.Str) > 0 {
+		str = e.Str[0]
+	}
+	writeString(out, prefix, "msgstr", str)
+}
+
+// writeReferences writes the references space separated, wrapping the lines
+// at referencesWidth like the gettext tools do.
+func writeReferences(out *bufio.Writer, references []string) {
+	line := "#:"
+	for _, reference := range references {
+		if len(line) > 2 && len(line)+1+len(reference) > referencesWidth {
+			out.WriteString(line + "\n")
+			line = "#:"
+		}
+		line += " " + reference
+	}
+	if len(line) > 2 {
+		out.WriteString(line + "\n")
+	}
+}
+
+// writeString writes a keyword and its quoted value, splitting multi-line
+// values in one string per line.
+func writeString(out *bufio.Writer, prefix string, keyword string, value string) {
+	lines := strings.SplitAfter(value, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) <= 1 {
+		fmt.Fprintf(out, "%s%s %s\n", prefix, keyword, quote(value))
+		return
+	}
+
+	fmt.Fprintf(out, "%s%s \"\"\n", prefix, keyword)
+	for _, line := range lines {
+		fmt.Fprintf(out, "%s%s\n", prefix, quote(line))
+	}
+}
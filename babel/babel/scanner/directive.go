@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// directivePrefix starts the comments marking a translation call with
+// intentionally dynamic keys, optionally followed by the values the keys can
+// take:
+//
+//	//babel:dynamic "Pending" "Paid" "Shipped"
+//	babel.Tr(locale, status)
+//
+// The directive goes at the end of the line of the call or on the line
+// before it. For Trn and Trnc calls the values are singular and plural pairs.
+const directivePrefix = "babel:dynamic"
+
+type directive struct {
+	position token.Position
+	values   []string
+	used     bool
+}
+
+// goString matches a Go interpreted or raw string literal.
+var goString = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`")
+
+// templateDirective matches a directive written as a template comment.
+var templateDirective = regexp.MustCompile(`\{\{-?\s*/\*\s*` + directivePrefix + `((?s:.*?))\*/\s*-?\}\}`)
+
+// addDirective parses the values following the directive prefix.
+func (s *scanner) addDirective(position token.Position, text string) {
+	d := &directive{position: position}
+	for _, literal := range goString.FindAllString(text, -1) {
+		value, err := strconv.Unquote(literal)
+		if err != nil {
+			s.reportAt(position, fmt.Sprintf("invalid %s value %s", directivePrefix, literal))
+			continue
+		}
+		d.values = append(d.values, value)
+	}
+	s.directives[position.Line] = d
+}
+
+// goDirectives finds the directives in the comments of a Go file.
+func (s *scanner) goDirectives(file *ast.File) {
+	s.directives = map[int]*directive{}
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			if strings.HasPrefix(text, directivePrefix) {
+				s.addDirective(s.fileset.Position(comment.Pos()), text[len(directivePrefix):])
+			}
+		}
+	}
+}
+
+// templateDirectives finds the directives in the comments of a template.
+func (s *scanner) templateDirectives(filename string, text string) {
+	s.directives = map[int]*directive{}
+	for _, match := range templateDirective.FindAllStringSubmatchIndex(text, -1) {
+		position := token.Position{Filename: filename, Line: 1 + strings.Count(text[:match[0]], "\n")}
+		s.addDirective(position, text[match[2]:match[3]])
+	}
+}
+
+// directive returns the directive of a call in the given line, if any.
+func (s *scanner) directive(line int) *directive {
+	if d, ok := s.directives[line]; ok {
+		return d
+	}
+	return s.directives[line-1]
+}
+
+// unusedDirectives reports the directives of the file that no call used.
+func (s *scanner) unusedDirectives() {
+	var lines []int
+	for line, d := range s.directives {
+		if !d.used {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	for _, line := range lines {
+		s.reportAt(s.directives[line].position, fmt.Sprintf("%s directive without a translation call with dynamic keys", directivePrefix))
+	}
+}
@@ -0,0 +1,303 @@
+package scanner
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// imports holds the names a file uses to refer to the babel package, and
+// whether it imports a package whose context translates.
+type imports struct {
+	names   map[string]bool
+	dot     bool
+	context bool
+}
+
+func fileImports(file *ast.File) *imports {
+	imp := &imports{names: map[string]bool{}}
+
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if contextPackagePaths[path] {
+			imp.context = true
+		}
+		if !packagePaths[path] {
+			continue
+		}
+
+		switch {
+		case spec.Name == nil:
+			imp.names[path[strings.LastIndex(path, "/")+1:]] = true
+		case spec.Name.Name == ".":
+			imp.dot = true
+		case spec.Name.Name != "_":
+			imp.names[spec.Name.Name] = true
+		}
+	}
+
+	return imp
+}
+
+func (imp *imports) empty() bool {
+	return len(imp.names) == 0 && !imp.dot && !imp.context
+}
+
+// translationFunc returns the name of the babel translation function called,
+// if any.
+func (imp *imports) translationFunc(call *ast.CallExpr) (string, bool) {
+	name, ok := imp.packageName(call.Fun)
+	if !ok {
+		return "", false
+	}
+	_, ok = signatures[name]
+	return name, ok
+}
+
+// packageName returns the name of the babel package member the expression
+// refers to, if any. Identifiers bound to a local declaration shadow the
+// package name.
+func (imp *imports) packageName(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok || pkg.Obj != nil || !imp.names[pkg.Name] {
+			return "", false
+		}
+		return e.Sel.Name, true
+	case *ast.Ident:
+		if !imp.dot || e.Obj != nil {
+			return "", false
+		}
+		return e.Name, true
+	}
+	return "", false
+}
+
+// isTranslatorType reports whether the type expression is *babel.Translator.
+func (imp *imports) isTranslatorType(expr ast.Expr) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	name, ok := imp.packageName(star.X)
+	return ok && name == translatorType
+}
+
+// isTranslatorCall reports whether the expression calls a babel function
+// returning a *babel.Translator, such as babel.Default().
+func (imp *imports) isTranslatorCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	name, ok := imp.packageName(call.Fun)
+	return ok && translatorFuncs[name]
+}
+
+// declaresTranslator reports whether the declaration of the named variable,
+// parameter or field makes it a *babel.Translator, by its type or by its
+// value.
+func (imp *imports) declaresTranslator(decl interface{}, name string) bool {
+	switch d := decl.(type) {
+	case *ast.Field:
+		return imp.isTranslatorType(d.Type)
+	case *ast.ValueSpec:
+		if imp.isTranslatorType(d.Type) {
+			return true
+		}
+		for i, ident := range d.Names {
+			if ident.Name == name && i < len(d.Values) {
+				return imp.isTranslatorCall(d.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		if len(d.Lhs) != len(d.Rhs) {
+			return false
+		}
+		for i, lhs := range d.Lhs {
+			if ident, ok := lhs.(*ast.Ident); ok && ident.Name == name {
+				return imp.isTranslatorCall(d.Rhs[i])
+			}
+		}
+	}
+	return false
+}
+
+// isTranslator reports whether the receiver of a method call is a
+// *babel.Translator: a call to babel.NewTranslator or babel.Default, or a
+// variable, parameter or struct field of the package declared as one.
+func (s *scanner) isTranslator(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return s.isTranslator(e.X)
+	case *ast.CallExpr:
+		return s.imports.isTranslatorCall(e)
+	case *ast.Ident:
+		if e.Obj == nil {
+			return s.pkg.translators[e.Name]
+		}
+		return s.imports.declaresTranslator(e.Obj.Decl, e.Name)
+	case *ast.SelectorExpr:
+		return s.pkg.translators[e.Sel.Name]
+	}
+	return false
+}
+
+// translatorMethod returns the name of the *babel.Translator translation
+// method called, if any. Its arguments are the ones of the babel function.
+func (s *scanner) translatorMethod(call *ast.CallExpr) (string, bool) {
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	if _, ok := signatures[fun.Sel.Name]; !ok || !s.isTranslator(fun.X) {
+		return "", false
+	}
+	return fun.Sel.Name, true
+}
+
+// translationMethod returns the name of the translation method called and
+// the babel function it wraps, if any. Methods are only looked for in the
+// files importing a package whose context translates, and never on the
+// babel package itself.
+func (imp *imports) translationMethod(call *ast.CallExpr, methods map[string]string) (string, string, bool) {
+	if !imp.context {
+		return "", "", false
+	}
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	if pkg, ok := fun.X.(*ast.Ident); ok && pkg.Obj == nil && imp.names[pkg.Name] {
+		return "", "", false
+	}
+
+	name, ok := methods[fun.Sel.Name]
+	if !ok {
+		return "", "", false
+	}
+	_, ok = signatures[name]
+	return fun.Sel.Name, name, ok
+}
+
+// stringValue resolves a key argument made of string literals, constants and
+// concatenations of both.
+func (s *scanner) stringValue(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return value, err == nil
+	case *ast.ParenExpr:
+		return s.stringValue(e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := s.stringValue(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := s.stringValue(e.Y)
+		return x + y, ok
+	case *ast.Ident:
+		return s.constant(e)
+	}
+	return "", false
+}
+
+// constant resolves the value of a constant declared in the same file, at
+// any scope, or at the package level of any file of the package.
+func (s *scanner) constant(ident *ast.Ident) (string, bool) {
+	if ident.Obj == nil {
+		if value, ok := s.pkg.constants[ident.Name]; ok {
+			return s.stringValue(value)
+		}
+		return "", false
+	}
+
+	if ident.Obj.Kind != ast.Con {
+		return "", false
+	}
+	spec, ok := ident.Obj.Decl.(*ast.ValueSpec)
+	if !ok {
+		return "", false
+	}
+	for i, name := range spec.Names {
+		if name.Name == ident.Name && i < len(spec.Values) {
+			return s.stringValue(spec.Values[i])
+		}
+	}
+	return "", false
+}
+
+// packageScope holds the package level declarations of the files of a
+// package, resolved across all of them.
+type packageScope struct {
+	constants map[string]ast.Expr
+	// translators are the variables and struct fields declared as a
+	// *babel.Translator.
+	translators map[string]bool
+}
+
+// packageDecls returns the package level declarations of the files of the
+// package in dir, parsing them on the first call.
+func (s *scanner) packageDecls(dir string, pkgName string) *packageScope {
+	key := dir + ":" + pkgName
+	if scope, ok := s.packages[key]; ok {
+		return scope
+	}
+
+	scope := &packageScope{constants: map[string]ast.Expr{}, translators: map[string]bool{}}
+	pkgs, _ := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return strings.HasSuffix(info.Name(), ".go")
+	}, 0)
+
+	if pkg, ok := pkgs[pkgName]; ok {
+		for _, file := range pkg.Files {
+			imp := fileImports(file)
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					value := spec.(*ast.ValueSpec)
+					for i, name := range value.Names {
+						switch {
+						case gen.Tok == token.CONST && i < len(value.Values):
+							scope.constants[name.Name] = value.Values[i]
+						case gen.Tok == token.VAR && imp.declaresTranslator(value, name.Name):
+							scope.translators[name.Name] = true
+						}
+					}
+				}
+			}
+
+			ast.Inspect(file, func(node ast.Node) bool {
+				if structType, ok := node.(*ast.StructType); ok {
+					for _, field := range structType.Fields.List {
+						if imp.isTranslatorType(field.Type) {
+							for _, name := range field.Names {
+								scope.translators[name.Name] = true
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	s.packages[key] = scope
+	return scope
+}
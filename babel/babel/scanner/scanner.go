@@ -1,134 +1,430 @@
 package scanner
 
 import (
-	"bytes"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
 	"github.com/pkg/errors"
 )
 
 const (
-	packageName  = "babel"
-	singularFunc = "Tr"
-	pluralFunc   = "Trn"
+	singularFunc        = "Tr"
+	pluralFunc          = "Trn"
+	contextSingularFunc = "Trc"
+	contextPluralFunc   = "Trnc"
+	messageFunc         = "Trf"
+
+	// translatorType is the type whose methods translate like the babel
+	// functions, taking the same arguments.
+	translatorType = "Translator"
 )
 
-type references []string
+// translatorFuncs are the babel functions returning a *babel.Translator.
+var translatorFuncs = map[string]bool{
+	"NewTranslator": true,
+	"Default":       true,
+}
+
+// packagePaths are the import paths the babel library is published under.
+var packagePaths = map[string]bool{
+	"github.com/mercadolibre/coreservices-team/babel": true,
+	"github.com/mercadolibre/go-meli-toolkit/babel":   true,
+}
+
+// contextPackagePaths are the import paths of the packages whose request
+// context has translation methods, such as gk.Context.
+var contextPackagePaths = map[string]bool{
+	"github.com/mercadolibre/coreservices-team/gk": true,
+}
+
+// DefaultMethods maps the translation methods of gk.Context to the babel
+// function they wrap. Their arguments are the ones of the babel function
+// without the locale, which comes from the request.
+var DefaultMethods = map[string]string{
+	singularFunc:        singularFunc,
+	pluralFunc:          pluralFunc,
+	contextSingularFunc: contextSingularFunc,
+	contextPluralFunc:   contextPluralFunc,
+	messageFunc:         messageFunc,
+}
+
+// signature holds the position of the key arguments of a translation
+// function, -1 when the function doesn't take that argument.
+type signature struct {
+	context  int
+	singular int
+	plural   int
+}
+
+func (s signature) arity() int {
+	arity := s.singular
+	if s.context > arity {
+		arity = s.context
+	}
+	if s.plural > arity {
+		arity = s.plural
+	}
+	return arity + 1
+}
+
+var signatures = map[string]signature{
+	singularFunc:        {context: -1, singular: 1, plural: -1},
+	pluralFunc:          {context: -1, singular: 2, plural: 3},
+	contextSingularFunc: {context: 1, singular: 2, plural: -1},
+	contextPluralFunc:   {context: 1, singular: 3, plural: 4},
+	messageFunc:         {context: -1, singular: 1, plural: -1},
+}
+
+// Diagnostic is a problem found in a translation call while scanning.
+type Diagnostic struct {
+	Position token.Position
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Position, d.Message)
+}
+
+type references []token.Position
+
+// strings returns the references as file:line, sorted and without the
+// duplicates of several calls in the same line.
+func (r references) strings() []string {
+	sorted := append(references(nil), r...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Filename != sorted[j].Filename {
+			return sorted[i].Filename < sorted[j].Filename
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	var result []string
+	for _, position := range sorted {
+		reference := fmt.Sprintf("%s:%d", position.Filename, position.Line)
+		if len(result) == 0 || result[len(result)-1] != reference {
+			result = append(result, reference)
+		}
+	}
+	return result
+}
 
 type translation interface {
-	Serialize() string
+	entry() *po.Entry
 }
 
 type singularized struct {
-	text string
+	context string
+	text    string
 }
 
-func (s singularized) Serialize() string {
-	var buffer bytes.Buffer
-	buffer.WriteString(fmt.Sprintf("msgid %s\n", s.text))
-	buffer.WriteString(fmt.Sprintf("msgstr %s\n\n", s.text))
-	return buffer.String()
+func (s singularized) entry() *po.Entry {
+	return &po.Entry{
+		Context: s.context,
+		ID:      s.text,
+		Str:     []string{s.text},
+	}
 }
 
 type pluralized struct {
+	context  string
 	singular string
 	plural   string
 }
 
-func (s pluralized) Serialize() string {
-	var buffer bytes.Buffer
-	buffer.WriteString(fmt.Sprintf("msgid %s\n", s.singular))
-	buffer.WriteString(fmt.Sprintf("msgid_plural %s\n", s.plural))
-	buffer.WriteString(fmt.Sprintf("msgstr[0] %s\n", s.singular))
-	buffer.WriteString(fmt.Sprintf("msgstr[1] %s\n\n", s.plural))
-	return buffer.String()
+func (s pluralized) entry() *po.Entry {
+	return &po.Entry{
+		Context:  s.context,
+		ID:       s.singular,
+		IDPlural: s.plural,
+		Str:      []string{s.singular, s.plural},
+	}
 }
 
 type scanner struct {
 	translations map[translation]references
 	fileset      *token.FileSet
+	diagnostics  []Diagnostic
+	dynamic      []Diagnostic
+
+	// packages caches the package level declarations of every scanned
+	// package.
+	packages map[string]*packageScope
+
+	// methods maps the translation methods to the babel function they wrap.
+	methods map[string]string
+
+	// state of the file being scanned
+	imports    *imports
+	pkg        *packageScope
+	directives map[int]*directive
 }
 
 func NewFileScanner() *scanner {
-	return &scanner{translations: make(map[translation]references), fileset: token.NewFileSet()}
+	return &scanner{
+		translations: make(map[translation]references),
+		fileset:      token.NewFileSet(),
+		packages:     make(map[string]*packageScope),
+		methods:      DefaultMethods,
+	}
+}
+
+// SetMethods sets the translation methods looked for in the files importing
+// gk, mapping each method name to the babel function it wraps.
+func (s *scanner) SetMethods(methods map[string]string) {
+	s.methods = methods
 }
 
 func (s *scanner) Scan(filename string) error {
-	file, err := parser.ParseFile(s.fileset, filename, nil, 0)
+	file, err := parser.ParseFile(s.fileset, filename, nil, parser.ParseComments)
 	if err != nil {
 		return err
 	}
+
+	s.imports = fileImports(file)
+	s.pkg = s.packageDecls(filepath.Dir(filename), file.Name.Name)
+	if s.imports.empty() && len(s.pkg.translators) == 0 {
+		return nil
+	}
+	s.goDirectives(file)
+
 	ast.Walk(s, file)
+	s.unusedDirectives()
 	return nil
 }
 
+// Diagnostics returns the problems found in the translation calls of the
+// scanned files, such as calls missing arguments.
+func (s *scanner) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+// DynamicKeys returns the translation calls whose keys aren't string
+// literals or constants, and so can't be extracted, unless they are marked
+// with a babel:dynamic directive.
+func (s *scanner) DynamicKeys() []Diagnostic {
+	return s.dynamic
+}
+
+// Save writes the found translations to filename, sorted so that scanning the
+// same code always produces the same file.
 func (s *scanner) Save(filename string) error {
-	var buffer bytes.Buffer
+	file := &po.File{Entries: s.entries()}
+	file.Sort()
+	return write(filename, file)
+}
 
-	for translation, references := range s.translations {
-		for _, reference := range references {
-			buffer.WriteString(fmt.Sprintf("#: %s\n", reference))
+// Merge updates filename with the found translations, keeping the comments,
+// flags and msgstr of the entries already in it. Entries no longer found in
+// the code are kept as obsolete instead of being dropped.
+func (s *scanner) Merge(filename string) error {
+	fd, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return s.Save(filename)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error trying to open the existing file")
+	}
+	existing, err := po.Parse(fd)
+	fd.Close()
+	if err != nil {
+		return errors.Wrap(err, "Error trying to parse the existing file")
+	}
+
+	found := map[string]*po.Entry{}
+	for _, entry := range s.entries() {
+		found[entry.Key()] = entry
+	}
+
+	merged := &po.File{}
+	seen := map[string]bool{}
+	for _, old := range existing.Entries {
+		key := old.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if old.IsHeader() {
+			merged.Entries = append(merged.Entries, old)
+			continue
+		}
+
+		entry, ok := found[key]
+		if !ok {
+			old.Obsolete = true
+			old.References = nil
+			merged.Entries = append(merged.Entries, old)
+			continue
+		}
+
+		// keep what people wrote on the entry, refresh what comes from the code
+		entry.Comments = old.Comments
+		entry.Extracted = old.Extracted
+		entry.Flags = old.Flags
+		entry.Previous = old.Previous
+		if old.IDPlural == entry.IDPlural && len(old.Str) > 0 {
+			entry.Str = old.Str
+		}
+		merged.Entries = append(merged.Entries, entry)
+	}
+
+	for key, entry := range found {
+		if !seen[key] {
+			merged.Entries = append(merged.Entries, entry)
 		}
-		buffer.WriteString(translation.Serialize())
 	}
 
+	merged.Sort()
+	return write(filename, merged)
+}
+
+func (s *scanner) entries() []*po.Entry {
+	entries := make([]*po.Entry, 0, len(s.translations))
+	for translation, references := range s.translations {
+		entry := translation.entry()
+		entry.References = references.strings()
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func write(filename string, file *po.File) error {
 	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
 		return errors.Wrap(err, "Error trying to create the output dir")
 	}
-	if output, err := os.Create(filename); err != nil {
+	output, err := os.Create(filename)
+	if err != nil {
 		return errors.Wrap(err, "Error trying to create the output file")
-	} else {
-		defer output.Close()
-		io.WriteString(output, buffer.String())
 	}
-	return nil
+	defer output.Close()
+
+	return file.Write(output)
 }
 
 func (s *scanner) Visit(node ast.Node) ast.Visitor {
-	ok := false
-
 	// search for function calls
-	var call *ast.CallExpr
-	if call, ok = node.(*ast.CallExpr); !ok {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return s
+	}
+
+	// check if the call is babel.T(locale, "some key", ...), under any alias,
+	// or the same method of a *babel.Translator
+	name, ok := s.imports.translationFunc(call)
+	if !ok {
+		name, ok = s.translatorMethod(call)
+	}
+	if !ok {
+		return s.visitMethod(call)
+	}
+
+	sig := signatures[name]
+	if len(call.Args) < sig.arity() {
+		s.report(call, fmt.Sprintf("%s expects at least %d arguments, found %d", name, sig.arity(), len(call.Args)))
 		return s
 	}
 
-	// only functions with a selector (foo.bar(), nothing.Do(), fmt.Println("hello world"))
-	var fun *ast.SelectorExpr
-	if fun, ok = call.Fun.(*ast.SelectorExpr); !ok {
+	s.addCall(name, sig, s.fileset.Position(call.Pos()), func(i int) (string, bool) {
+		return s.stringValue(call.Args[i])
+	})
+	return s
+}
+
+// visitMethod adds the translation of a call to a translation method, such
+// as ctx.Tr("some key", ...), whose key arguments come one position earlier
+// than in the babel function since the locale is left out.
+func (s *scanner) visitMethod(call *ast.CallExpr) ast.Visitor {
+	method, name, ok := s.imports.translationMethod(call, s.methods)
+	if !ok {
 		return s
 	}
 
-	// check the selector identifier
-	var pkg *ast.Ident
-	if pkg, ok = fun.X.(*ast.Ident); !ok {
+	sig := signatures[name]
+	if len(call.Args) < sig.arity()-1 {
+		s.report(call, fmt.Sprintf("%s expects at least %d arguments, found %d", method, sig.arity()-1, len(call.Args)))
 		return s
 	}
 
-	// check if the call is babel.T(locale, "some key", ...)
-	if pkg.Name == packageName {
-		if fun.Sel.Name == singularFunc {
-			if literal, ok := call.Args[1].(*ast.BasicLit); ok && literal.Kind == token.STRING {
-				s.addTranslation(singularized{literal.Value}, node)
-			}
-		} else if fun.Sel.Name == pluralFunc {
-			if singular, ok := call.Args[2].(*ast.BasicLit); ok && singular.Kind == token.STRING {
-				if plural, ok := call.Args[3].(*ast.BasicLit); ok && plural.Kind == token.STRING {
-					s.addTranslation(pluralized{singular.Value, plural.Value}, node)
-				}
-			}
+	s.addCall(method, sig, s.fileset.Position(call.Pos()), func(i int) (string, bool) {
+		return s.stringValue(call.Args[i-1])
+	})
+	return s
+}
+
+// addCall adds the translation of a call to a translation function, resolve
+// returning the string value of its i-th argument. When the keys can't be
+// resolved the values of the babel:dynamic directive of the call are added
+// instead, and calls without one are reported as dynamic.
+func (s *scanner) addCall(name string, sig signature, position token.Position, resolve func(i int) (string, bool)) {
+	var context, singular, plural string
+	var dynamic []string
+	var ok bool
+
+	if sig.context >= 0 {
+		if context, ok = resolve(sig.context); !ok {
+			dynamic = append(dynamic, "context")
+		}
+	}
+	if singular, ok = resolve(sig.singular); !ok {
+		dynamic = append(dynamic, "key")
+	}
+	if sig.plural >= 0 {
+		if plural, ok = resolve(sig.plural); !ok {
+			dynamic = append(dynamic, "plural key")
 		}
 	}
 
-	return s
+	if len(dynamic) == 0 {
+		if sig.plural < 0 {
+			s.addReference(singularized{context: context, text: singular}, position)
+		} else {
+			s.addReference(pluralized{context: context, singular: singular, plural: plural}, position)
+		}
+		return
+	}
+
+	d := s.directive(position.Line)
+	if d == nil {
+		s.dynamic = append(s.dynamic, Diagnostic{
+			Position: position,
+			Message:  fmt.Sprintf("%s called with a dynamic %s", name, strings.Join(dynamic, " and ")),
+		})
+		return
+	}
+	d.used = true
+
+	switch {
+	case len(d.values) == 0:
+	case dynamic[0] == "context":
+		s.reportAt(d.position, fmt.Sprintf("%s values can't be used with a dynamic context", directivePrefix))
+	case sig.plural < 0:
+		for _, value := range d.values {
+			s.addReference(singularized{context: context, text: value}, position)
+		}
+	case len(d.values)%2 != 0:
+		s.reportAt(d.position, fmt.Sprintf("%s values of %s must be singular and plural pairs", directivePrefix, name))
+	default:
+		for i := 0; i < len(d.values); i += 2 {
+			s.addReference(pluralized{context: context, singular: d.values[i], plural: d.values[i+1]}, position)
+		}
+	}
+}
+
+func (s *scanner) report(node ast.Node, message string) {
+	s.reportAt(s.fileset.Position(node.Pos()), message)
+}
+
+func (s *scanner) reportAt(position token.Position, message string) {
+	s.diagnostics = append(s.diagnostics, Diagnostic{Position: position, Message: message})
 }
 
-func (s *scanner) addTranslation(t translation, node ast.Node) {
-	s.translations[t] = append(s.translations[t], s.fileset.Position(node.Pos()).String())
+func (s *scanner) addReference(t translation, position token.Position) {
+	s.translations[t] = append(s.translations[t], position)
 }
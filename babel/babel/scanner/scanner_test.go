@@ -0,0 +1,363 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mercadolibre/coreservices-team/babel/babel/po"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanSources writes the given files to a temporary package directory and
+// scans them.
+func scanSources(t *testing.T, files map[string]string) *scanner {
+	dir, err := ioutil.TempDir("", "scanner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := NewFileScanner()
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0666))
+	}
+	for name := range files {
+		require.NoError(t, s.Scan(filepath.Join(dir, name)))
+	}
+	return s
+}
+
+func TestScanAliasedImports(t *testing.T) {
+	s := scanSources(t, map[string]string{
+		"main.go": `package main
+
+import (
+	i18n "github.com/mercadolibre/coreservices-team/babel"
+	"golang.org/x/text/language"
+)
+
+const greeting = "Hello " + "%s"
+
+func main() {
+	const bye = "Bye"
+	i18n.Tr(language.Spanish, greeting, "world")
+	i18n.Tr(language.Spanish, bye)
+	i18n.Trc(language.Spanish, "door", shared)
+	i18n.Trn(language.Spanish, 2, "%d item", "%d items" + "")
+	i18n.Trf(language.Spanish, "{count, plural, one {# item} other {# items}}", i18n.Args{"count": 2})
+}
+`,
+		"keys.go": `package main
+
+const shared = "Open"
+`,
+		"other.go": `package main
+
+import "fmt"
+
+type fake struct{}
+
+func (fake) Tr(args ...interface{}) {}
+
+func other() {
+	babel := fake{}
+	babel.Tr(nil, "Shadowed")
+	fmt.Println("Not a key")
+}
+`,
+	})
+
+	assert.Len(t, s.translations, 5)
+	assert.Contains(t, s.translations, singularized{text: "Hello %s"})
+	assert.Contains(t, s.translations, singularized{text: "{count, plural, one {# item} other {# items}}"})
+	assert.Contains(t, s.translations, singularized{text: "Bye"})
+	assert.Contains(t, s.translations, singularized{context: "door", text: "Open"})
+	assert.Contains(t, s.translations, pluralized{singular: "%d item", plural: "%d items"})
+	assert.Empty(t, s.Diagnostics())
+}
+
+func TestScanMalformedCalls(t *testing.T) {
+	s := scanSources(t, map[string]string{
+		"main.go": `package main
+
+import . "github.com/mercadolibre/coreservices-team/babel"
+
+func main() {
+	Tr(nil)
+	Trn(nil, 1, "%d item")
+	Tr(nil, "Dot imported")
+}
+`,
+	})
+
+	assert.Len(t, s.translations, 1)
+	assert.Contains(t, s.translations, singularized{text: "Dot imported"})
+
+	require.Len(t, s.Diagnostics(), 2)
+	assert.Equal(t, 6, s.Diagnostics()[0].Position.Line)
+	assert.Equal(t, "Tr expects at least 2 arguments, found 1", s.Diagnostics()[0].Message)
+	assert.Equal(t, 7, s.Diagnostics()[1].Position.Line)
+}
+
+const mergeSource = `package main
+
+import "github.com/mercadolibre/coreservices-team/babel"
+
+func main() {
+	babel.Tr(nil, "Zebra")
+	babel.Tr(nil, "Apple")
+	babel.Tr(nil, "Apple")
+}
+`
+
+func TestSaveIsSorted(t *testing.T) {
+	s := scanSources(t, map[string]string{"main.go": mergeSource})
+
+	output, err := ioutil.TempFile("", "source.po")
+	require.NoError(t, err)
+	output.Close()
+	defer os.Remove(output.Name())
+
+	require.NoError(t, s.Save(output.Name()))
+	content, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+
+	apple := strings.Index(string(content), `msgid "Apple"`)
+	zebra := strings.Index(string(content), `msgid "Zebra"`)
+	assert.True(t, apple >= 0 && apple < zebra)
+	assert.Regexp(t, `#: \S+main.go:7 \S+main.go:8\n`, string(content))
+}
+
+func TestMerge(t *testing.T) {
+	s := scanSources(t, map[string]string{"main.go": mergeSource})
+
+	output, err := ioutil.TempFile("", "source.po")
+	require.NoError(t, err)
+	defer os.Remove(output.Name())
+
+	output.WriteString(`# keep this comment
+#, c-format
+#: old.go:1
+msgid "Apple"
+msgstr "Apple"
+
+msgid "Removed"
+msgstr "Removed"
+`)
+	output.Close()
+
+	require.NoError(t, s.Merge(output.Name()))
+
+	content, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+	file, err := po.Parse(bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Len(t, file.Entries, 3)
+
+	apple := file.Entries[0]
+	assert.Equal(t, "Apple", apple.ID)
+	assert.Equal(t, []string{"keep this comment"}, apple.Comments)
+	assert.Equal(t, []string{"c-format"}, apple.Flags)
+	assert.NotContains(t, apple.References, "old.go:1")
+
+	assert.Equal(t, "Zebra", file.Entries[1].ID)
+	assert.False(t, file.Entries[1].Obsolete)
+
+	assert.Equal(t, "Removed", file.Entries[2].ID)
+	assert.True(t, file.Entries[2].Obsolete)
+}
+
+func TestScanTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scanner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "email.tmpl")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`{{define "body"}}
+<h1>{{ tr "Hello %s" .Name }}</h1>
+{{ if .Items }}
+  {{ trn (len .Items) "%d item" "%d items" (len .Items) | upper }}
+{{ else }}
+  {{ trc "cart" "Empty" }}
+{{ end }}
+{{ tr .Dynamic }}
+{{ trn 1 }}
+{{end}}
+`), 0666))
+
+	s := NewFileScanner()
+	require.NoError(t, s.ScanTemplate(filename, DefaultTemplateFuncs))
+
+	assert.Len(t, s.translations, 3)
+	assert.Equal(t, references{{Filename: filename, Line: 2}}, s.translations[singularized{text: "Hello %s"}])
+	assert.Equal(t, references{{Filename: filename, Line: 4}}, s.translations[pluralized{singular: "%d item", plural: "%d items"}])
+	assert.Contains(t, s.translations, singularized{context: "cart", text: "Empty"})
+
+	require.Len(t, s.Diagnostics(), 1)
+	assert.Equal(t, 9, s.Diagnostics()[0].Position.Line)
+	assert.Equal(t, "trn expects at least 3 arguments, found 1", s.Diagnostics()[0].Message)
+}
+
+func TestScanInvalidTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scanner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.html")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`<p>{{ if }}</p>`), 0666))
+
+	s := NewFileScanner()
+	require.NoError(t, s.ScanTemplate(filename, DefaultTemplateFuncs))
+	assert.Empty(t, s.translations)
+	assert.Len(t, s.Diagnostics(), 1)
+}
+
+func TestScanContextMethods(t *testing.T) {
+	s := scanSources(t, map[string]string{
+		"handler.go": `package handler
+
+import "github.com/mercadolibre/coreservices-team/gk"
+
+const cartContext = "cart"
+
+func Handle(c *gin.Context, ctx *gk.Context, status string, n int) {
+	ctx.Tr("Hello %s", "world")
+	ctx.Trn(n, "%d item", "%d items", n)
+	ctx.Trnc(cartContext, n, "%d product", "%d products", n)
+	ctx.Trf("{count, plural, one {# file} other {# files}}", nil)
+	ctx.Tr(status)
+	ctx.Trc("cart")
+	c.JSON(200, nil)
+}
+`,
+		// files not importing gk aren't scanned for methods
+		"other.go": `package handler
+
+func other(t translator) {
+	t.Tr("Not a key")
+}
+`,
+	})
+
+	assert.Len(t, s.translations, 4)
+	assert.Contains(t, s.translations, singularized{text: "Hello %s"})
+	assert.Contains(t, s.translations, pluralized{singular: "%d item", plural: "%d items"})
+	assert.Contains(t, s.translations, pluralized{context: "cart", singular: "%d product", plural: "%d products"})
+	assert.Contains(t, s.translations, singularized{text: "{count, plural, one {# file} other {# files}}"})
+
+	require.Len(t, s.DynamicKeys(), 1)
+	assert.Equal(t, 12, s.DynamicKeys()[0].Position.Line)
+	assert.Equal(t, "Tr called with a dynamic key", s.DynamicKeys()[0].Message)
+
+	require.Len(t, s.Diagnostics(), 1)
+	assert.Equal(t, "Trc expects at least 2 arguments, found 1", s.Diagnostics()[0].Message)
+}
+
+func TestScanTranslatorMethods(t *testing.T) {
+	s := scanSources(t, map[string]string{
+		"handler.go": `package handler
+
+import (
+	"github.com/mercadolibre/coreservices-team/babel"
+	"github.com/mercadolibre/coreservices-team/gk"
+)
+
+type service struct {
+	translator *babel.Translator
+}
+
+func (s *service) Handle(ctx *gk.Context, local *babel.Translator, n int) {
+	shared.Tr(ctx.Locale, "Hello")
+	babel.Default().Trn(ctx.Locale, n, "%d file", "%d files", n)
+	s.translator.Trc(ctx.Locale, "door", "Open")
+	local.Trnc(ctx.Locale, "cart", n, "%d item", "%d items", n)
+	product := babel.NewTranslator(shared)
+	product.Tr(ctx.Locale, "Bye")
+	ctx.Tr("Welcome")
+}
+`,
+		// a package level translator declared in another file
+		"translator.go": `package handler
+
+import "github.com/mercadolibre/coreservices-team/babel"
+
+var shared = babel.NewTranslator()
+`,
+	})
+
+	assert.Empty(t, s.DynamicKeys())
+	assert.Empty(t, s.Diagnostics())
+	assert.Len(t, s.translations, 6)
+	assert.Contains(t, s.translations, singularized{text: "Hello"})
+	assert.Contains(t, s.translations, pluralized{singular: "%d file", plural: "%d files"})
+	assert.Contains(t, s.translations, singularized{context: "door", text: "Open"})
+	assert.Contains(t, s.translations, pluralized{context: "cart", singular: "%d item", plural: "%d items"})
+	assert.Contains(t, s.translations, singularized{text: "Bye"})
+	assert.Contains(t, s.translations, singularized{text: "Welcome"})
+}
+
+func TestScanDynamicKeys(t *testing.T) {
+	s := scanSources(t, map[string]string{
+		"main.go": `package main
+
+import "github.com/mercadolibre/coreservices-team/babel"
+
+func main(status, door string, n int) {
+	babel.Tr(nil, status)
+	babel.Trc(nil, door, "Open")
+
+	//babel:dynamic "Pending" "Paid"
+	babel.Tr(nil, status)
+	babel.Trn(nil, n, status, status) //babel:dynamic "%d apple" "%d apples"
+	babel.Trn(nil, n, status, "%d items") //babel:dynamic "odd"
+
+	// babel:dynamic
+	babel.Tr(nil, status)
+
+	//babel:dynamic "Lost"
+	babel.Tr(nil, "Literal")
+}
+`,
+	})
+
+	assert.Len(t, s.translations, 4)
+	assert.Contains(t, s.translations, singularized{text: "Pending"})
+	assert.Contains(t, s.translations, singularized{text: "Paid"})
+	assert.Contains(t, s.translations, pluralized{singular: "%d apple", plural: "%d apples"})
+	assert.Contains(t, s.translations, singularized{text: "Literal"})
+
+	require.Len(t, s.DynamicKeys(), 2)
+	assert.Equal(t, 6, s.DynamicKeys()[0].Position.Line)
+	assert.Equal(t, "Tr called with a dynamic key", s.DynamicKeys()[0].Message)
+	assert.Equal(t, 7, s.DynamicKeys()[1].Position.Line)
+	assert.Equal(t, "Trc called with a dynamic context", s.DynamicKeys()[1].Message)
+
+	require.Len(t, s.Diagnostics(), 2)
+	assert.Equal(t, 12, s.Diagnostics()[0].Position.Line)
+	assert.Equal(t, "babel:dynamic values of Trn must be singular and plural pairs", s.Diagnostics()[0].Message)
+	assert.Equal(t, 17, s.Diagnostics()[1].Position.Line)
+}
+
+func TestScanTemplateDynamicKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scanner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "status.tmpl")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`{{ tr .Status }}
+{{/* babel:dynamic "Pending" "Paid" */}}
+{{ tr .Status }}
+`), 0666))
+
+	s := NewFileScanner()
+	require.NoError(t, s.ScanTemplate(filename, DefaultTemplateFuncs))
+
+	assert.Len(t, s.translations, 2)
+	assert.Contains(t, s.translations, singularized{text: "Pending"})
+	require.Len(t, s.DynamicKeys(), 1)
+	assert.Equal(t, 1, s.DynamicKeys()[0].Position.Line)
+	assert.Empty(t, s.Diagnostics())
+}
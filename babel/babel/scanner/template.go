@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template/parse"
+)
+
+// DefaultTemplateFuncs maps the template functions that translate to the babel
+// function they wrap, with the locale already bound. Their arguments are the
+// ones of the babel function without the locale, so that the key arguments
+// keep the index they have in a Go call, the function name taking the place
+// of the locale.
+var DefaultTemplateFuncs = map[string]string{
+	"tr":   singularFunc,
+	"trn":  pluralFunc,
+	"trc":  contextSingularFunc,
+	"trnc": contextPluralFunc,
+	"trf":  messageFunc,
+}
+
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// ScanTemplate finds the translation calls of an html/template or
+// text/template file, funcs mapping the template function names to the babel
+// function they wrap. Files that aren't valid templates are reported as a
+// diagnostic and skipped.
+func (s *scanner) ScanTemplate(filename string, funcs map[string]string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	text := string(content)
+
+	// The parser fails on undefined functions, and the templates use many
+	// we don't know about. Declaring every identifier as a function is
+	// harmless, keywords and fields are told apart before functions.
+	declared := map[string]interface{}{}
+	for _, name := range identifier.FindAllString(text, -1) {
+		declared[name] = true
+	}
+
+	trees, err := parse.Parse(filename, text, "", "", declared)
+	if err != nil {
+		s.reportAt(token.Position{Filename: filename}, fmt.Sprintf("not a valid template: %v", err))
+		return nil
+	}
+
+	s.templateDirectives(filename, text)
+	for _, tree := range trees {
+		s.walkTemplate(filename, text, funcs, tree.Root)
+	}
+	s.unusedDirectives()
+	return nil
+}
+
+func (s *scanner) walkTemplate(filename string, text string, funcs map[string]string, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			s.walkTemplate(filename, text, funcs, child)
+		}
+	case *parse.ActionNode:
+		s.walkTemplate(filename, text, funcs, n.Pipe)
+	case *parse.IfNode:
+		s.walkBranch(filename, text, funcs, &n.BranchNode)
+	case *parse.RangeNode:
+		s.walkBranch(filename, text, funcs, &n.BranchNode)
+	case *parse.WithNode:
+		s.walkBranch(filename, text, funcs, &n.BranchNode)
+	case *parse.TemplateNode:
+		s.walkTemplate(filename, text, funcs, n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			s.walkTemplate(filename, text, funcs, cmd)
+		}
+	case *parse.CommandNode:
+		s.templateCall(filename, text, funcs, n)
+		for _, arg := range n.Args {
+			s.walkTemplate(filename, text, funcs, arg)
+		}
+	}
+}
+
+func (s *scanner) walkBranch(filename string, text string, funcs map[string]string, n *parse.BranchNode) {
+	s.walkTemplate(filename, text, funcs, n.Pipe)
+	s.walkTemplate(filename, text, funcs, n.List)
+	s.walkTemplate(filename, text, funcs, n.ElseList)
+}
+
+// templateCall adds the translation of a template command calling one of the
+// translation functions.
+func (s *scanner) templateCall(filename string, text string, funcs map[string]string, cmd *parse.CommandNode) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return
+	}
+	name, ok := funcs[ident.Ident]
+	if !ok {
+		return
+	}
+	sig, ok := signatures[name]
+	if !ok {
+		return
+	}
+
+	position := token.Position{
+		Filename: filename,
+		Line:     1 + strings.Count(text[:int(cmd.Position())], "\n"),
+	}
+
+	if len(cmd.Args) < sig.arity() {
+		s.reportAt(position, fmt.Sprintf("%s expects at least %d arguments, found %d", ident.Ident, sig.arity()-1, len(cmd.Args)-1))
+		return
+	}
+
+	s.addCall(ident.Ident, sig, position, func(i int) (string, bool) {
+		str, ok := cmd.Args[i].(*parse.StringNode)
+		if !ok {
+			return "", false
+		}
+		return str.Text, true
+	})
+}
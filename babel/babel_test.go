@@ -0,0 +1,542 @@
+package babel
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+// writeBundle creates a bundle directory with one messages.po file per locale.
+func writeBundle(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "babel")
+	require.NoError(t, err)
+
+	for locale, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, locale), 0777))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, locale, "messages.po"), []byte(content), 0666))
+	}
+	return dir
+}
+
+func TestTrFallback(t *testing.T) {
+	translator := NewTranslator()
+
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Cart"
+msgstr "Carrito"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgid "Total"
+msgstr "Total"
+`,
+		"es-AR": `
+msgid "Hello"
+msgstr "Hola che"
+`,
+		"pt-BR": `
+msgid "Hello"
+msgstr "Olá"
+`,
+		"en": `
+msgid "Bye"
+msgstr "Bye bye"
+
+msgid "Total"
+msgstr "Grand total"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	translator.SetDefaultLocale(language.English)
+	require.NoError(t, translator.LoadDir(dir))
+
+	tt := []struct {
+		Locale   string
+		Key      string
+		Expected string
+	}{
+		{"es-AR", "Hello", "Hola che"},
+		{"es-AR", "Cart", "Carrito"},
+		{"es-UY", "Cart", "Carrito"},
+		{"pt", "Hello", "Olá"},
+		{"fr", "Bye", "Bye bye"},
+		{"fr", "Missing", "Missing"},
+		// a translation identical to its key is found, not a miss
+		{"es-AR", "Total", "Total"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Locale+"/"+tc.Key, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, translator.Tr(language.MustParse(tc.Locale), tc.Key))
+		})
+	}
+}
+
+func TestTrnPluralRules(t *testing.T) {
+	translator := NewTranslator()
+
+	dir := writeBundle(t, map[string]string{
+		// no header, CLDR rule for fr takes 0 as singular
+		"fr": `
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d fichier"
+msgstr[1] "%d fichiers"
+`,
+		// the bundle header wins over CLDR
+		"fr-CA": `
+msgid ""
+msgstr ""
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d fichier"
+msgstr[1] "%d fichiers"
+`,
+		// a header without Plural-Forms gets the CLDR rule for pt
+		"pt-BR": `
+msgid ""
+msgstr ""
+"Language: pt_BR\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d arquivo"
+msgstr[1] "%d arquivos"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, translator.LoadDir(dir))
+
+	tt := []struct {
+		Locale   string
+		Count    int
+		Expected string
+	}{
+		{"fr", 0, "0 fichier"},
+		{"fr", 2, "2 fichiers"},
+		{"fr-CA", 0, "0 fichiers"},
+		{"fr-CA", 1, "1 fichier"},
+		{"pt-BR", 0, "0 arquivo"},
+		{"pt-BR", 2, "2 arquivos"},
+		// not loaded, untranslated keys use the CLDR rule of the locale
+		{"es", 0, "0 files"},
+		{"es", 1, "1 file"},
+		{"ja", 1, "1 files"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Locale, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, translator.Trn(language.MustParse(tc.Locale), tc.Count, "%d file", "%d files", tc.Count))
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	translator := NewTranslator()
+
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Hello"
+msgstr "Hola"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, translator.LoadDir(dir))
+
+	// keep translating while the bundle is reloaded, to be checked with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			translator.Tr(language.Spanish, "Hello")
+		}
+	}()
+
+	content := []byte("msgid \"Hello\"\nmsgstr \"Buenas\"\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "es", "messages.po"), content, 0666))
+	require.NoError(t, translator.Reload())
+	<-done
+
+	assert.Equal(t, "Buenas", translator.Tr(language.Spanish, "Hello"))
+}
+
+func TestReloadKeepsTranslationsOnError(t *testing.T) {
+	translator := NewTranslator()
+
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Hello"
+msgstr "Hola"
+`,
+	})
+
+	require.NoError(t, translator.LoadDir(dir))
+	require.NoError(t, os.RemoveAll(dir))
+
+	assert.Error(t, translator.Reload())
+	assert.Equal(t, "Hola", translator.Tr(language.Spanish, "Hello"))
+}
+
+func TestLayeredTranslators(t *testing.T) {
+	sharedDir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Cancel"
+msgstr "Cancelar"
+
+msgid "Buy"
+msgstr "Comprar"
+`,
+	})
+	defer os.RemoveAll(sharedDir)
+
+	productDir := writeBundle(t, map[string]string{
+		"es-AR": `
+msgid "Buy"
+msgstr "Comprá"
+`,
+		"en": `
+msgid "Cancel"
+msgstr "Cancel order"
+`,
+	})
+	defer os.RemoveAll(productDir)
+
+	shared := NewTranslator()
+	require.NoError(t, shared.LoadDir(sharedDir))
+
+	product := NewTranslator(shared)
+	product.SetDefaultLocale(language.English)
+	require.NoError(t, product.LoadDir(productDir))
+
+	locale := language.MustParse("es-AR")
+	assert.Equal(t, "Comprá", product.Tr(locale, "Buy"))
+	// the shared es bundle is preferred over the product default locale
+	assert.Equal(t, "Cancelar", product.Tr(locale, "Cancel"))
+	assert.Equal(t, "Cancel order", product.Tr(language.French, "Cancel"))
+	assert.Equal(t, "Comprar", shared.Tr(locale, "Buy"))
+}
+
+func TestTrc(t *testing.T) {
+	translator := NewTranslator()
+
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgctxt "door"
+msgid "Open"
+msgstr "Abrir"
+
+msgctxt "store"
+msgid "Open"
+msgstr "Abierto"
+
+msgctxt "orders"
+msgid "%d order"
+msgid_plural "%d orders"
+msgstr[0] "%d pedido"
+msgstr[1] "%d pedidos"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, translator.LoadDir(dir))
+
+	assert.Equal(t, "Abrir", translator.Trc(language.Spanish, "door", "Open"))
+	assert.Equal(t, "Abierto", translator.Trc(language.Spanish, "store", "Open"))
+	assert.Equal(t, "Open", translator.Trc(language.Spanish, "menu", "Open"))
+	assert.Equal(t, "2 pedidos", translator.Trnc(language.Spanish, "orders", 2, "%d order", "%d orders", 2))
+	assert.Equal(t, "0 sorts", translator.Trnc(language.Spanish, "sorting", 0, "%d sort", "%d sorts", 0))
+}
+
+func TestLoadReader(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	writer := zip.NewWriter(buffer)
+	file, err := writer.Create("es_AR/messages.po")
+	require.NoError(t, err)
+	file.Write([]byte("msgid \"Hello\"\nmsgstr \"Hola\"\n"))
+	require.NoError(t, writer.Close())
+
+	translator := NewTranslator()
+	require.NoError(t, translator.LoadReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len())))
+	assert.Equal(t, "Hola", translator.Tr(language.MustParse("es-AR"), "Hello"))
+
+	assert.Error(t, translator.LoadReader(bytes.NewReader([]byte("not a zip")), 9))
+}
+
+func TestLoadFS(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"pt-BR": `
+msgid "Hello"
+msgstr "Olá"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	translator := NewTranslator()
+	require.NoError(t, translator.LoadFS(http.Dir(dir)))
+	assert.Equal(t, "Olá", translator.Tr(language.MustParse("pt-BR"), "Hello"))
+	assert.Equal(t, []language.Tag{language.MustParse("pt-BR")}, translator.Locales())
+}
+
+func TestOnMissing(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Hello"
+msgstr "Hola"
+
+msgid "OK"
+msgstr "OK"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	translator := NewTranslator()
+	require.NoError(t, translator.LoadDir(dir))
+
+	var misses []Miss
+	collector := NewMissingCollector()
+	translator.OnMissing(MissingHandlers(collector.Handle, func(miss Miss) { misses = append(misses, miss) }))
+
+	translator.Tr(language.Spanish, "Hello")
+	translator.Tr(language.Spanish, "OK")
+	translator.Tr(language.Spanish, "Bye")
+	translator.Tr(language.MustParse("pt-BR"), "Bye")
+	translator.Trnc(language.Spanish, "cart", 2, "%d item", "%d items", 2)
+
+	require.Len(t, misses, 3)
+	assert.Equal(t, Miss{Locale: language.Spanish, Context: "cart", Key: "%d item", Plural: "%d items", Caller: misses[2].Caller}, misses[2])
+	assert.Regexp(t, `babel_test\.go:\d+$`, misses[0].Caller)
+	assert.Equal(t, 2, collector.Len())
+
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, collector.WritePo(buffer))
+	assert.Regexp(t, `^#\. missing in es
+#: \S+babel_test\.go:\d+
+msgctxt "cart"
+msgid "%d item"
+msgid_plural "%d items"
+msgstr\[0\] ""
+msgstr\[1\] ""
+
+#\. missing in es, pt-BR
+#: \S+babel_test\.go:\d+
+#: \S+babel_test\.go:\d+
+msgid "Bye"
+msgstr ""
+$`, buffer.String())
+
+	translator.OnMissing(nil)
+	translator.Tr(language.Spanish, "Other")
+	assert.Len(t, misses, 3)
+}
+
+// compileMo builds a little endian .mo file with the given msgid, msgstr pairs.
+func compileMo(messages [][2]string) []byte {
+	count := uint32(len(messages))
+	offset := 28 + 16*count
+	var data bytes.Buffer
+	var tables [2][]uint32
+	for i := range tables {
+		for _, message := range messages {
+			tables[i] = append(tables[i], uint32(len(message[i])), offset+uint32(data.Len()))
+			data.WriteString(message[i] + "\x00")
+		}
+	}
+
+	var mo bytes.Buffer
+	for _, value := range []uint32{0x950412de, 0, count, 28, 28 + 8*count, 0, 0} {
+		binary.Write(&mo, binary.LittleEndian, value)
+	}
+	for _, table := range tables {
+		binary.Write(&mo, binary.LittleEndian, table)
+	}
+	mo.Write(data.Bytes())
+	return mo.Bytes()
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "babel")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "es"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "es", "messages.mo"), compileMo([][2]string{
+		{"", "Plural-Forms: nplurals=2; plural=(n != 1);\n"},
+		{"Hello", "Hola"},
+		{"door\x04Open", "Abrir"},
+		{"%d item\x00%d items", "%d artículo\x00%d artículos"},
+	}), 0666))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pt-BR"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pt-BR", "messages.json"), []byte(`{
+	"Hello": "Olá",
+	"door\u0004Open": "Abrir",
+	"%d item": ["%d item", "%d itens"]
+}`), 0666))
+
+	translator := NewTranslator()
+	require.NoError(t, translator.LoadDir(dir))
+
+	tt := []struct {
+		Locale string
+		Hello  string
+		Items  [3]string
+	}{
+		{"es", "Hola", [3]string{"0 artículos", "1 artículo", "2 artículos"}},
+		// no header, the CLDR rule for pt takes 0 as singular
+		{"pt-BR", "Olá", [3]string{"0 item", "1 item", "2 itens"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Locale, func(t *testing.T) {
+			tag := language.MustParse(tc.Locale)
+			assert.Equal(t, tc.Hello, translator.Tr(tag, "Hello"))
+			assert.Equal(t, "Abrir", translator.Trc(tag, "door", "Open"))
+			assert.Equal(t, "Open", translator.Tr(tag, "Open"))
+			for count, expected := range tc.Items {
+				assert.Equal(t, expected, translator.Trn(tag, count, "%d item", "%d items", count))
+			}
+		})
+	}
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "es", "messages.mo"), []byte("not a mo file"), 0666))
+	assert.Error(t, NewTranslator().LoadDir(dir))
+}
+
+func TestLoadMergesLocaleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "babel")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{
+		"es/backend.po": []byte(`msgid ""
+msgstr ""
+"Language: es\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgid "Save"
+msgstr "Guardar"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d archivo"
+msgstr[1] "%d archivos"
+`),
+		// its header has no Plural-Forms, the one of backend.po is kept
+		"es/frontend.json": []byte(`{
+	"": "Language: es\n",
+	"Save": "Guardar cambios",
+	"Close": "Cerrar"
+}`),
+		// a .mo header without Plural-Forms gets the CLDR rule for pt
+		"pt-BR/messages.mo": compileMo([][2]string{
+			{"", "Language: pt_BR\n"},
+			{"%d file\x00%d files", "%d arquivo\x00%d arquivos"},
+		}),
+	}
+	for name, content := range files {
+		fullpath := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullpath), 0777))
+		require.NoError(t, ioutil.WriteFile(fullpath, content, 0666))
+	}
+
+	translator := NewTranslator()
+	require.NoError(t, translator.LoadDir(dir))
+
+	assert.Equal(t, "Hola", translator.Tr(language.Spanish, "Hello"))
+	assert.Equal(t, "Cerrar", translator.Tr(language.Spanish, "Close"))
+	assert.Equal(t, "Guardar cambios", translator.Tr(language.Spanish, "Save"))
+	assert.Equal(t, "1 archivo", translator.Trn(language.Spanish, 1, "%d file", "%d files", 1))
+	assert.Equal(t, "0 archivo", translator.Trn(language.Spanish, 0, "%d file", "%d files", 0))
+	assert.Equal(t, "0 arquivo", translator.Trn(language.MustParse("pt-BR"), 0, "%d file", "%d files", 0))
+	assert.Equal(t, "2 arquivos", translator.Trn(language.MustParse("pt-BR"), 2, "%d file", "%d files", 2))
+
+	// the bundles loaded later add up to the same locale too
+	other := writeBundle(t, map[string]string{
+		"es": `
+msgid "Bye"
+msgstr "Chau"
+`,
+	})
+	defer os.RemoveAll(other)
+
+	require.NoError(t, translator.LoadDir(other))
+	assert.Equal(t, "Chau", translator.Tr(language.Spanish, "Bye"))
+	assert.Equal(t, "Hola", translator.Tr(language.Spanish, "Hello"))
+	assert.Equal(t, "0 archivo", translator.Trn(language.Spanish, 0, "%d file", "%d files", 0))
+
+	require.NoError(t, translator.Reload())
+	assert.Equal(t, "Chau", translator.Tr(language.Spanish, "Bye"))
+	assert.Equal(t, "Guardar cambios", translator.Tr(language.Spanish, "Save"))
+}
+
+func TestWatch(t *testing.T) {
+	translator := NewTranslator()
+
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Hello"
+msgstr "Hola"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, translator.LoadDir(dir))
+
+	goroutines := runtime.NumGoroutine()
+	errs := make(chan error, 10)
+	stop := translator.Watch(10*time.Millisecond, func(err error) { errs <- err })
+
+	content := []byte("msgid \"Hello\"\nmsgstr \"Buenas\"\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "es", "messages.po"), content, 0666))
+	assert.Eventually(t, func() bool {
+		return translator.Tr(language.Spanish, "Hello") == "Buenas"
+	}, time.Second, 10*time.Millisecond)
+
+	// a .po file that can't be opened fails the reload
+	broken := filepath.Join(dir, "es", "broken.po")
+	require.NoError(t, os.Symlink(filepath.Join(dir, "missing.po"), broken))
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "broken.po")
+	case <-time.After(time.Second):
+		t.Fatal("the reload error was not reported")
+	}
+	assert.Equal(t, "Buenas", translator.Tr(language.Spanish, "Hello"))
+
+	stop()
+	stop()
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatal("the watcher did not stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a stopped watcher no longer reloads
+	require.NoError(t, os.Remove(broken))
+	content = []byte("msgid \"Hello\"\nmsgstr \"Buen día\"\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "es", "messages.po"), content, 0666))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "Buenas", translator.Tr(language.Spanish, "Hello"))
+}
@@ -0,0 +1,72 @@
+package babel
+
+import (
+	"sort"
+
+	"golang.org/x/text/language"
+)
+
+// catalog is an immutable set of loaded translations together with the
+// matcher used to resolve fallbacks between them.
+type catalog struct {
+	translations map[string]*messages
+
+	// defaultLocale is the last link of every fallback chain.
+	defaultLocale language.Tag
+
+	// supported holds the tags of the loaded locales, in the same order the
+	// matcher was built with, so a match index can be mapped back to a tag.
+	supported []language.Tag
+	matcher   language.Matcher
+}
+
+func newCatalog(translations map[string]*messages, defaultLocale language.Tag) *catalog {
+	c := &catalog{
+		translations:  translations,
+		defaultLocale: defaultLocale,
+		supported:     make([]language.Tag, 0, len(translations)),
+	}
+
+	for locale := range translations {
+		if tag, err := language.Parse(locale); err == nil {
+			c.supported = append(c.supported, tag)
+		}
+	}
+	// map iteration is random, keep the matcher tie-breaking deterministic
+	sort.Slice(c.supported, func(i, j int) bool {
+		return c.supported[i].String() < c.supported[j].String()
+	})
+
+	if len(c.supported) > 0 {
+		c.matcher = language.NewMatcher(c.supported)
+	}
+	return c
+}
+
+// fallbacks returns the loaded translations able to serve the given locale,
+// most specific first: the locale itself, its parents (es-UY -> es-419 -> es)
+// and the closest loaded variant (pt -> pt-BR). The default locale is left
+// for the caller, to be tried once every layer has been walked.
+func (c *catalog) fallbacks(locale language.Tag) []*messages {
+	var chain []*messages
+	seen := map[string]bool{}
+
+	add := func(tag language.Tag) {
+		key := tag.String()
+		if translation, ok := c.translations[key]; ok && !seen[key] {
+			seen[key] = true
+			chain = append(chain, translation)
+		}
+	}
+
+	for tag := locale; tag != language.Und; tag = tag.Parent() {
+		add(tag)
+	}
+	if c.matcher != nil {
+		if _, index, confidence := c.matcher.Match(locale); confidence != language.No {
+			add(c.supported[index])
+		}
+	}
+
+	return chain
+}
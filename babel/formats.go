@@ -0,0 +1,166 @@
+package babel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// decoders convert the supported bundle files, by extension, to .po content.
+var decoders = map[string]func([]byte) ([]byte, error){
+	".po":   func(content []byte) ([]byte, error) { return content, nil },
+	".mo":   decodeMo,
+	".json": decodeJSON,
+}
+
+// IsBundleFile reports whether the file has the extension of a supported
+// format: .po, .mo or .json.
+func IsBundleFile(name string) bool {
+	_, ok := decoders[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+// DecodeFile converts the content of the named bundle file to .po content,
+// the one format the translators and the babel command read in the end.
+func DecodeFile(name string, content []byte) ([]byte, error) {
+	decoder, ok := decoders[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported bundle format", name)
+	}
+	content, err := decoder(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return content, nil
+}
+
+const (
+	moMagic        = 0x950412de
+	moMagicSwapped = 0xde120495
+)
+
+// decodeMo converts a compiled .mo file to .po content. Contexts and plural
+// forms are kept: msgids are "context\x04id" and plural msgids and msgstrs
+// join their forms with NUL bytes.
+func decodeMo(content []byte) ([]byte, error) {
+	if len(content) < 20 {
+		return nil, fmt.Errorf("invalid .mo file: too short")
+	}
+
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(content) {
+	case moMagic:
+		order = binary.LittleEndian
+	case moMagicSwapped:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid .mo file: bad magic number")
+	}
+
+	count := order.Uint32(content[8:])
+	originals := order.Uint32(content[12:])
+	translations := order.Uint32(content[16:])
+
+	// str reads the i-th string of the table at offset.
+	str := func(table uint32, i uint32) (string, error) {
+		entry := uint64(table) + uint64(i)*8
+		if entry+8 > uint64(len(content)) {
+			return "", fmt.Errorf("invalid .mo file: string table out of range")
+		}
+		length := uint64(order.Uint32(content[entry:]))
+		offset := uint64(order.Uint32(content[entry+4:]))
+		if offset+length > uint64(len(content)) {
+			return "", fmt.Errorf("invalid .mo file: string out of range")
+		}
+		return string(content[offset : offset+length]), nil
+	}
+
+	var b bytes.Buffer
+	for i := uint32(0); i < count; i++ {
+		id, err := str(originals, i)
+		if err != nil {
+			return nil, err
+		}
+		translation, err := str(translations, i)
+		if err != nil {
+			return nil, err
+		}
+
+		var context string
+		if sep := strings.IndexByte(id, '\x04'); sep >= 0 {
+			context, id = id[:sep], id[sep+1:]
+		}
+		ids := strings.Split(id, "\x00")
+		writePoEntry(&b, context, ids[0], ids[1:], strings.Split(translation, "\x00"))
+	}
+	return b.Bytes(), nil
+}
+
+// decodeJSON converts a JSON key/value bundle to .po content. Values are a
+// translation, or an array with one translation per plural form. Keys with a
+// context are written as "context\u0004key", like in .mo files. An optional
+// "" key holds the .po header, Plural-Forms included.
+func decodeJSON(content []byte) ([]byte, error) {
+	var messages map[string]interface{}
+	if err := json.Unmarshal(content, &messages); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	// the header, if any, goes first
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, key := range keys {
+		var context string
+		id := key
+		if sep := strings.IndexByte(key, '\x04'); sep >= 0 {
+			context, id = key[:sep], key[sep+1:]
+		}
+
+		switch value := messages[key].(type) {
+		case string:
+			writePoEntry(&b, context, id, nil, []string{value})
+		case []interface{}:
+			forms := make([]string, len(value))
+			for i, form := range value {
+				text, ok := form.(string)
+				if !ok {
+					return nil, fmt.Errorf("%q: plural forms must be strings", key)
+				}
+				forms[i] = text
+			}
+			// lookups are done by the singular key, so it stands in for the
+			// plural one too
+			writePoEntry(&b, context, id, []string{id}, forms)
+		default:
+			return nil, fmt.Errorf("%q: translations must be a string or an array of strings", key)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// writePoEntry writes a message in .po format. Messages with a plural id get
+// one msgstr[n] per form.
+func writePoEntry(b *bytes.Buffer, context string, id string, plural []string, forms []string) {
+	if context != "" {
+		fmt.Fprintf(b, "msgctxt %s\n", quote(context))
+	}
+	fmt.Fprintf(b, "msgid %s\n", quote(id))
+	if len(plural) == 0 {
+		fmt.Fprintf(b, "msgstr %s\n\n", quote(strings.Join(forms, "")))
+		return
+	}
+	fmt.Fprintf(b, "msgid_plural %s\n", quote(plural[0]))
+	for i, form := range forms {
+		fmt.Fprintf(b, "msgstr[%d] %s\n", i, quote(form))
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,140 @@
+package babel
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// FormatNumber formats the number with the digit grouping and decimal
+// separator of the locale, 1234.5 being "1,234.5" in en and "1.234,5" in
+// es-AR. The options set the number of digits.
+func FormatNumber(locale language.Tag, value interface{}, options ...number.Option) string {
+	return message.NewPrinter(locale).Sprint(number.Decimal(value, options...))
+}
+
+// FormatPercent formats the ratio as a percentage of the locale, 0.25 being
+// "25%".
+func FormatPercent(locale language.Tag, value interface{}, options ...number.Option) string {
+	return message.NewPrinter(locale).Sprint(number.Percent(value, options...))
+}
+
+// currencyFormats are the spaces written between the symbol of a currency and
+// the amount by the languages of the sites, the first one being the fallback
+// of the other languages.
+var currencyFormats = []struct {
+	tag   language.Tag
+	space string
+}{
+	{language.English, ""},
+	{language.Spanish, " "},
+	{language.Portuguese, " "},
+}
+
+var currencyMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(currencyFormats))
+	for i, format := range currencyFormats {
+		tags[i] = format.tag
+	}
+	return language.NewMatcher(tags)
+}()
+
+// FormatCurrency formats the amount of the currency with the given ISO 4217
+// code, using the symbol of the currency in the locale and the amount rounded
+// to the digits of the currency. Spanish and Portuguese separate the symbol
+// from the amount, English only separates a symbol ending in a letter:
+// "$ 1.234,50" for ARS in es-AR, "$1,234.50" for USD and "ARS 1,234.50" for
+// ARS in en.
+func FormatCurrency(locale language.Tag, amount float64, code string) (string, error) {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", err
+	}
+
+	printer := message.NewPrinter(locale)
+	scale, _ := currency.Standard.Rounding(unit)
+	symbol := printer.Sprint(currency.Symbol(unit))
+	formatted := printer.Sprint(number.Decimal(amount, number.Scale(scale)))
+
+	_, index, confidence := currencyMatcher.Match(locale)
+	if confidence == language.No {
+		index = 0
+	}
+	space := currencyFormats[index].space
+	if last, _ := utf8.DecodeLastRuneInString(symbol); unicode.IsLetter(last) {
+		space = " "
+	}
+	return symbol + space + formatted, nil
+}
+
+// DateStyle is the length of a formatted date.
+type DateStyle int
+
+const (
+	// ShortDate is the numeric date, "02/01/2006" in es.
+	ShortDate DateStyle = iota
+	// LongDate spells out the month, "2 de enero de 2006" in es.
+	LongDate
+)
+
+// dateFormat holds how a language writes dates.
+type dateFormat struct {
+	short  func(t time.Time) string
+	long   func(t time.Time, month string) string
+	months [12]string
+}
+
+// dateFormats are the date formats of the languages of the sites, the first
+// one being the fallback of the other languages.
+var dateFormats = []struct {
+	tag    language.Tag
+	format dateFormat
+}{
+	{language.English, dateFormat{
+		short: func(t time.Time) string { return t.Format("1/2/2006") },
+		long:  func(t time.Time, month string) string { return fmt.Sprintf("%s %d, %d", month, t.Day(), t.Year()) },
+		months: [12]string{"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December"},
+	}},
+	{language.Spanish, dateFormat{
+		short: func(t time.Time) string { return t.Format("02/01/2006") },
+		long:  func(t time.Time, month string) string { return fmt.Sprintf("%d de %s de %d", t.Day(), month, t.Year()) },
+		months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio",
+			"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	}},
+	{language.Portuguese, dateFormat{
+		short: func(t time.Time) string { return t.Format("02/01/2006") },
+		long:  func(t time.Time, month string) string { return fmt.Sprintf("%d de %s de %d", t.Day(), month, t.Year()) },
+		months: [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+			"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+	}},
+}
+
+var dateMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(dateFormats))
+	for i, format := range dateFormats {
+		tags[i] = format.tag
+	}
+	return language.NewMatcher(tags)
+}()
+
+// FormatDate formats the date in the style of the locale. Spanish, Portuguese
+// and English are supported, other languages get the English format.
+func FormatDate(locale language.Tag, t time.Time, style DateStyle) string {
+	_, index, confidence := dateMatcher.Match(locale)
+	if confidence == language.No {
+		index = 0
+	}
+	format := dateFormats[index].format
+
+	if style == LongDate {
+		return format.long(t, format.months[t.Month()-1])
+	}
+	return format.short(t)
+}
@@ -0,0 +1,81 @@
+package babel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"golang.org/x/text/number"
+)
+
+func TestFormatNumber(t *testing.T) {
+	tt := []struct {
+		Locale   string
+		Value    interface{}
+		Options  []number.Option
+		Expected string
+	}{
+		{"en", 1234.5, nil, "1,234.5"},
+		{"es-AR", 1234.5, nil, "1.234,5"},
+		{"pt-BR", 1234567, nil, "1.234.567"},
+		{"es-AR", 3.14159, []number.Option{number.MaxFractionDigits(2)}, "3,14"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Locale+"/"+tc.Expected, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, FormatNumber(language.MustParse(tc.Locale), tc.Value, tc.Options...))
+		})
+	}
+
+	assert.Equal(t, "25%", FormatPercent(language.English, 0.25))
+}
+
+func TestFormatCurrency(t *testing.T) {
+	tt := []struct {
+		Locale   string
+		Amount   float64
+		Code     string
+		Expected string
+	}{
+		{"es-AR", 1234.5, "ARS", "$ 1.234,50"},
+		{"pt-BR", 1234.5, "BRL", "R$ 1.234,50"},
+		{"en", 1234.5, "USD", "$1,234.50"},
+		{"en-GB", 1234.5, "ARS", "ARS 1,234.50"},
+		{"es-CL", 1234, "CLP", "$ 1.234"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Locale+"/"+tc.Code, func(t *testing.T) {
+			formatted, err := FormatCurrency(language.MustParse(tc.Locale), tc.Amount, tc.Code)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, formatted)
+		})
+	}
+
+	_, err := FormatCurrency(language.English, 1, "XYZW")
+	assert.Error(t, err)
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2020, time.March, 7, 10, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		Locale   string
+		Style    DateStyle
+		Expected string
+	}{
+		{"es-AR", ShortDate, "07/03/2020"},
+		{"es-MX", LongDate, "7 de marzo de 2020"},
+		{"pt-BR", LongDate, "7 de março de 2020"},
+		{"en", ShortDate, "3/7/2020"},
+		{"en", LongDate, "March 7, 2020"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Locale+"/"+tc.Expected, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, FormatDate(language.MustParse(tc.Locale), date, tc.Style))
+		})
+	}
+}
@@ -0,0 +1,570 @@
+package babel
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/number"
+)
+
+// Args are the named arguments of an ICU message.
+type Args map[string]interface{}
+
+// Trf translates an ICU MessageFormat key and formats it with the named
+// arguments, for messages that depend on selects, plurals with exact values
+// or offsets, or more than one count:
+//
+//	{gender, select, female {She} male {He} other {They}} invited {count, plural,
+//	    =0 {nobody} =1 {{host}} one {{host} and one friend} other {{host} and # friends}}
+//
+// The translations are parsed when their bundle is loaded. The ones that
+// aren't valid messages are reported to the OnInvalid handler, and skipped
+// like missing ones, so the fallback locales are tried and the miss is
+// reported.
+func (t *Translator) Trf(locale language.Tag, key string, args Args) string {
+	var message icuMessage
+	_, ok := t.lookup(locale, func(translation *messages) (string, bool) {
+		parsed, ok := translation.icu[key]
+		message = parsed
+		return "", ok
+	})
+	if !ok {
+		t.missing(locale, "", key, "")
+		return formatMessage(locale, key, args)
+	}
+	return message.text(locale, args)
+}
+
+// formatMessage formats the ICU message, returning it as is when it is not
+// valid.
+func formatMessage(locale language.Tag, text string, args Args) string {
+	message, err := parseMessage(text)
+	if err != nil {
+		return text
+	}
+	return message.text(locale, args)
+}
+
+// InvalidMessage is a translation of an ICU key that isn't a valid message,
+// found when its bundle was loaded.
+type InvalidMessage struct {
+	Locale language.Tag
+	Key    string
+	Err    error
+}
+
+func (m InvalidMessage) String() string {
+	return fmt.Sprintf("[%s] %q: invalid translation: %v", m.Locale, m.Key, m.Err)
+}
+
+// InvalidHandler is called with every invalid ICU translation of the locales
+// loaded by Load, LoadDir, LoadReader, LoadFS and Reload. It runs while the
+// bundles are being loaded, so it must not load bundles itself.
+type InvalidHandler func(InvalidMessage)
+
+// invalidHandler wraps the handler so it can be stored in an atomic.Value.
+type invalidHandler struct {
+	handle InvalidHandler
+}
+
+// OnInvalid sets the handler called with the invalid ICU translations found
+// when loading bundles, to be set before loading them. A nil handler
+// disables the reporting.
+func (t *Translator) OnInvalid(handler InvalidHandler) {
+	t.onInvalid.Store(invalidHandler{handle: handler})
+}
+
+// reportInvalid reports the invalid translations of the locales that the
+// last load changed, in locale order.
+func (t *Translator) reportInvalid(translations map[string]*messages, previous map[string]*messages) {
+	handler, _ := t.onInvalid.Load().(invalidHandler)
+	if handler.handle == nil {
+		return
+	}
+
+	locales := make([]string, 0, len(translations))
+	for locale, translation := range translations {
+		if previous[locale] != translation {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+
+	for _, locale := range locales {
+		for _, invalid := range translations[locale].invalid {
+			handler.handle(invalid)
+		}
+	}
+}
+
+// looksLikeMessage matches the texts with an ICU argument.
+var looksLikeMessage = regexp.MustCompile(`\{\s*[\p{L}\p{N}_]+\s*[,}]`)
+
+// isMessageKey reports whether the key is a valid ICU message with
+// arguments, the keys whose translations must be valid messages too.
+func isMessageKey(key string) bool {
+	if !looksLikeMessage.MatchString(key) {
+		return false
+	}
+	_, err := parseMessage(key)
+	return err == nil
+}
+
+// ValidateMessage checks that the translation of an ICU MessageFormat key is
+// a valid message. Only the keys that are valid messages with arguments are
+// ICU keys: the translations of any other key, such as "Use {x, y}
+// syntax", are not checked.
+func ValidateMessage(key string, translation string) error {
+	if !isMessageKey(key) {
+		return nil
+	}
+	_, err := parseMessage(translation)
+	return err
+}
+
+type icuKind int
+
+const (
+	icuText icuKind = iota
+	icuHash
+	icuArgument
+	icuSelect
+	icuPlural
+)
+
+// icuMessage is a parsed ICU message: literal text, arguments and the # of
+// plural cases.
+type icuMessage []icuPart
+
+type icuPart struct {
+	kind icuKind
+	text string
+
+	// argument name, and its type and style for simple arguments
+	name  string
+	typ   string
+	style string
+
+	// select and plural arguments
+	ordinal bool
+	offset  float64
+	cases   []icuCase
+}
+
+type icuCase struct {
+	selector string
+	message  icuMessage
+}
+
+// pluralKeywords are the CLDR plural categories a plural case can select.
+var pluralKeywords = map[string]plural.Form{
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"other": plural.Other,
+}
+
+// parseMessage parses an ICU MessageFormat message.
+func parseMessage(text string) (icuMessage, error) {
+	p := &icuParser{text: []rune(text)}
+	message, err := p.message(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.text) {
+		return nil, p.errorf("unexpected }")
+	}
+	return message, nil
+}
+
+type icuParser struct {
+	text []rune
+	pos  int
+}
+
+func (p *icuParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *icuParser) peek(offset int) rune {
+	if p.pos+offset < len(p.text) {
+		return p.text[p.pos+offset]
+	}
+	return 0
+}
+
+func (p *icuParser) skipSpaces() {
+	for p.pos < len(p.text) && unicode.IsSpace(p.text[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *icuParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.text) && (unicode.IsLetter(p.text[p.pos]) || unicode.IsDigit(p.text[p.pos]) || p.text[p.pos] == '_') {
+		p.pos++
+	}
+	return string(p.text[start:p.pos])
+}
+
+func (p *icuParser) expect(r rune) error {
+	if p.peek(0) != r {
+		return p.errorf("expected %q", r)
+	}
+	p.pos++
+	return nil
+}
+
+// message parses text and arguments up to the end of the text or an
+// unmatched }, which is left for the caller.
+func (p *icuParser) message(inPlural bool) (icuMessage, error) {
+	var message icuMessage
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			message = append(message, icuPart{kind: icuText, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.text) {
+		switch r := p.text[p.pos]; {
+		case r == '}':
+			flush()
+			return message, nil
+		case r == '{':
+			flush()
+			p.pos++
+			part, err := p.argument(inPlural)
+			if err != nil {
+				return nil, err
+			}
+			message = append(message, part)
+		case r == '#' && inPlural:
+			flush()
+			p.pos++
+			message = append(message, icuPart{kind: icuHash})
+		case r == '\'':
+			p.quoted(&text, inPlural)
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+	flush()
+	return message, nil
+}
+
+// quoted handles an apostrophe: two of them are a literal apostrophe, and one
+// before a special character quotes the text up to the next one. Any other
+// apostrophe is literal.
+func (p *icuParser) quoted(text *strings.Builder, inPlural bool) {
+	next := p.peek(1)
+	switch {
+	case next == '\'':
+		text.WriteRune('\'')
+		p.pos += 2
+		return
+	case next == '{' || next == '}' || next == '|' || (next == '#' && inPlural):
+	default:
+		text.WriteRune('\'')
+		p.pos++
+		return
+	}
+
+	p.pos++
+	for p.pos < len(p.text) {
+		if p.text[p.pos] == '\'' {
+			if p.peek(1) == '\'' {
+				text.WriteRune('\'')
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			return
+		}
+		text.WriteRune(p.text[p.pos])
+		p.pos++
+	}
+}
+
+// argument parses an argument, right after its {.
+func (p *icuParser) argument(inPlural bool) (icuPart, error) {
+	p.skipSpaces()
+	part := icuPart{kind: icuArgument, name: p.identifier()}
+	if part.name == "" {
+		return part, p.errorf("expected an argument name")
+	}
+	p.skipSpaces()
+	if p.peek(0) == '}' {
+		p.pos++
+		return part, nil
+	}
+	if err := p.expect(','); err != nil {
+		return part, err
+	}
+
+	p.skipSpaces()
+	part.typ = p.identifier()
+	p.skipSpaces()
+
+	switch part.typ {
+	case "select":
+		part.kind = icuSelect
+		if err := p.expect(','); err != nil {
+			return part, err
+		}
+		return part, p.cases(&part, inPlural)
+	case "plural", "selectordinal":
+		part.kind = icuPlural
+		part.ordinal = part.typ == "selectordinal"
+		if err := p.expect(','); err != nil {
+			return part, err
+		}
+		p.skipSpaces()
+		if strings.HasPrefix(string(p.text[p.pos:]), "offset:") {
+			p.pos += len("offset:")
+			p.skipSpaces()
+			offset, err := strconv.ParseFloat(p.identifier(), 64)
+			if err != nil {
+				return part, p.errorf("invalid offset")
+			}
+			part.offset = offset
+		}
+		return part, p.cases(&part, true)
+	case "number", "date", "time":
+		if p.peek(0) == ',' {
+			p.pos++
+			start := p.pos
+			for p.pos < len(p.text) && p.text[p.pos] != '}' {
+				p.pos++
+			}
+			part.style = strings.TrimSpace(string(p.text[start:p.pos]))
+		}
+		return part, p.expect('}')
+	case "":
+		return part, p.errorf("expected an argument type")
+	default:
+		return part, p.errorf("unsupported argument type %s", part.typ)
+	}
+}
+
+// cases parses the cases of a select or plural argument, up to its closing }.
+// The # of the cases is the plural value within a plural argument, even in
+// nested selects.
+func (p *icuParser) cases(part *icuPart, inPlural bool) error {
+	isPlural := part.kind == icuPlural
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.text) {
+			return p.errorf("unclosed %s argument %s", part.typ, part.name)
+		}
+		if p.peek(0) == '}' {
+			p.pos++
+			break
+		}
+
+		var selector string
+		if p.peek(0) == '=' {
+			p.pos++
+			selector = p.identifier()
+			if _, err := strconv.ParseFloat(selector, 64); err != nil {
+				return p.errorf("invalid exact value =%s", selector)
+			}
+			selector = "=" + selector
+		} else {
+			selector = p.identifier()
+			if selector == "" {
+				return p.errorf("expected a case selector")
+			}
+			if _, ok := pluralKeywords[selector]; isPlural && !ok {
+				return p.errorf("unknown plural category %s", selector)
+			}
+		}
+
+		p.skipSpaces()
+		if err := p.expect('{'); err != nil {
+			return err
+		}
+		message, err := p.message(inPlural)
+		if err != nil {
+			return err
+		}
+		if err := p.expect('}'); err != nil {
+			return err
+		}
+		part.cases = append(part.cases, icuCase{selector: selector, message: message})
+	}
+
+	for _, c := range part.cases {
+		if c.selector == "other" {
+			return nil
+		}
+	}
+	return p.errorf("%s argument %s has no other case", part.typ, part.name)
+}
+
+// text formats the message with the arguments.
+func (m icuMessage) text(locale language.Tag, args Args) string {
+	var b strings.Builder
+	m.format(&b, locale, args, "")
+	return b.String()
+}
+
+// format writes the message with the arguments, hash being the value of the
+// # of the innermost plural case.
+func (m icuMessage) format(b *strings.Builder, locale language.Tag, args Args, hash string) {
+	for _, part := range m {
+		switch part.kind {
+		case icuText:
+			b.WriteString(part.text)
+		case icuHash:
+			// a # outside of any plural is parsed as text, but the
+			// cases of a select within a plural get the plural value
+			b.WriteString(hash)
+		case icuArgument:
+			value, ok := args[part.name]
+			if !ok {
+				fmt.Fprintf(b, "{%s}", part.name)
+				continue
+			}
+			b.WriteString(part.formatValue(locale, value))
+		case icuSelect:
+			part.choose(fmt.Sprint(args[part.name])).format(b, locale, args, hash)
+		case icuPlural:
+			n, ok := toNumber(args[part.name])
+			if !ok {
+				part.choose("other").format(b, locale, args, hash)
+				continue
+			}
+			part.choosePlural(locale, n).format(b, locale, args, FormatNumber(locale, n-part.offset))
+		}
+	}
+}
+
+// formatValue formats the value of a simple argument. Numbers and dates are
+// formatted for the locale, {n, number, percent} and {n, number, integer} as
+// percentages and integers, and {d, date, long} as long dates.
+func (part icuPart) formatValue(locale language.Tag, value interface{}) string {
+	if date, ok := value.(time.Time); ok {
+		if part.style == "long" || part.style == "full" {
+			return FormatDate(locale, date, LongDate)
+		}
+		return FormatDate(locale, date, ShortDate)
+	}
+
+	n, ok := toNumber(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	switch part.style {
+	case "percent":
+		return FormatPercent(locale, n)
+	case "integer":
+		return FormatNumber(locale, n, number.MaxFractionDigits(0))
+	default:
+		return FormatNumber(locale, n)
+	}
+}
+
+// choose returns the case with the selector, or the other case.
+func (part icuPart) choose(selector string) icuMessage {
+	var other icuMessage
+	for _, c := range part.cases {
+		if c.selector == selector {
+			return c.message
+		}
+		if c.selector == "other" {
+			other = c.message
+		}
+	}
+	return other
+}
+
+// choosePlural returns the case matching the number exactly, or else the
+// case of the plural category of the number minus the offset.
+func (part icuPart) choosePlural(locale language.Tag, n float64) icuMessage {
+	exact := "=" + formatNumber(n)
+	for _, c := range part.cases {
+		if c.selector == exact {
+			return c.message
+		}
+	}
+
+	rules := plural.Cardinal
+	if part.ordinal {
+		rules = plural.Ordinal
+	}
+	i, v, w, f, t := operands(n - part.offset)
+	form := rules.MatchPlural(locale, i, v, w, f, t)
+	for keyword, value := range pluralKeywords {
+		if value == form {
+			return part.choose(keyword)
+		}
+	}
+	return part.choose("other")
+}
+
+// operands returns the CLDR plural operands of the number: its integer
+// part, the number of visible fraction digits with and without trailing
+// zeros, and those fraction digits.
+func operands(number float64) (i, v, w, f, t int) {
+	if number < 0 {
+		number = -number
+	}
+	text := formatNumber(number)
+	integer, fraction := text, ""
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		integer, fraction = text[:dot], text[dot+1:]
+	}
+	i, _ = strconv.Atoi(integer)
+	v = len(fraction)
+	f, _ = strconv.Atoi(fraction)
+	trimmed := strings.TrimRight(fraction, "0")
+	w = len(trimmed)
+	t, _ = strconv.Atoi(trimmed)
+	return i, v, w, f, t
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
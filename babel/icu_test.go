@@ -0,0 +1,132 @@
+package babel
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+const invitation = "{gender, select, female {She} male {He} other {They}} invited {count, plural, offset:1 " +
+	"=0 {nobody} =1 {{host}} one {{host} and one friend} other {{host} and # friends}}"
+
+func TestFormatMessage(t *testing.T) {
+	tt := []struct {
+		Message  string
+		Args     Args
+		Expected string
+	}{
+		{invitation, Args{"gender": "female", "count": 0}, "She invited nobody"},
+		{invitation, Args{"gender": "male", "count": 1, "host": "Ana"}, "He invited Ana"},
+		{invitation, Args{"gender": "male", "count": 2, "host": "Ana"}, "He invited Ana and one friend"},
+		{invitation, Args{"count": 5, "host": "Ana"}, "They invited Ana and 4 friends"},
+		{"{pos, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", Args{"pos": 23}, "23rd"},
+		{"{n, plural, other {# {unit, select, kg {#kg} other {#}}}}", Args{"n": 1.5, "unit": "kg"}, "1.5 1.5kg"},
+		{"It''s '{'quoted'}' and '#'", nil, "It's {quoted} and '#'"},
+		{"Hi {name}, {missing}", Args{"name": "Bo"}, "Hi Bo, {missing}"},
+		{"{n} of {total, number, integer}", Args{"n": 1234.5, "total": 2000}, "1,234.5 of 2,000"},
+		{"{broken", Args{"broken": 1}, "{broken"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Expected, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, formatMessage(language.English, tc.Message, tc.Args))
+		})
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	for _, message := range []string{
+		"{a, select, x {y}}",
+		"{a, plural, one {x} other {y}",
+		"{a, plural, lots {x} other {y}}",
+		"{a, foo}",
+		"{}",
+		"}",
+	} {
+		_, err := parseMessage(message)
+		assert.Error(t, err, message)
+	}
+}
+
+func TestTrf(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "{count, plural, one {# item} other {# items}}"
+msgstr "{count, plural, one {# artículo} other {# artículos}}"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	translator := NewTranslator()
+	require.NoError(t, translator.LoadDir(dir))
+
+	key := "{count, plural, one {# item} other {# items}}"
+	assert.Equal(t, "1 artículo", translator.Trf(language.Spanish, key, Args{"count": 1}))
+	assert.Equal(t, "3 artículos", translator.Trf(language.Spanish, key, Args{"count": 3}))
+	assert.Equal(t, "3 items", translator.Trf(language.French, key, Args{"count": 3}))
+}
+
+func TestTrfSkipsInvalidTranslations(t *testing.T) {
+	dir := writeBundle(t, map[string]string{
+		"es": `
+msgid "Hello"
+msgstr "Hola"
+
+msgid "Use {x, y} syntax"
+msgstr "Usá la sintaxis {x, y}"
+
+msgid "{count, plural, one {# item} other {# items}}"
+msgstr "{count, plural, one {# artículo} otro {# artículos}}"
+`,
+		"pt": `
+msgid "{count, plural, one {# item} other {# items}}"
+msgstr "{count, plural, one {# item} other {# itens}}"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	// invalid messages don't keep the bundle from loading, they are reported
+	var invalid []string
+	translator := NewTranslator()
+	translator.OnInvalid(func(message InvalidMessage) { invalid = append(invalid, message.String()) })
+	translator.SetDefaultLocale(language.Portuguese)
+	require.NoError(t, translator.LoadDir(dir))
+	assert.Equal(t, []string{
+		`[es] "{count, plural, one {# item} other {# items}}": invalid translation: offset 37: unknown plural category otro`,
+	}, invalid)
+	assert.Equal(t, "Hola", translator.Tr(language.Spanish, "Hello"))
+	assert.Equal(t, "Usá la sintaxis {x, y}", translator.Tr(language.Spanish, "Use {x, y} syntax"))
+
+	var misses []Miss
+	translator.OnMissing(func(miss Miss) { misses = append(misses, miss) })
+
+	// the invalid translation is skipped in favor of the default locale
+	key := "{count, plural, one {# item} other {# items}}"
+	assert.Equal(t, "3 itens", translator.Trf(language.Spanish, key, Args{"count": 3}))
+	assert.Empty(t, misses)
+
+	// and reported again on every reload
+	require.NoError(t, translator.Reload())
+	assert.Len(t, invalid, 2)
+
+	translator = NewTranslator()
+	require.NoError(t, translator.LoadDir(dir))
+	translator.OnMissing(func(miss Miss) { misses = append(misses, miss) })
+	assert.Equal(t, "3 items", translator.Trf(language.Spanish, key, Args{"count": 3}))
+	require.Len(t, misses, 1)
+	assert.Equal(t, key, misses[0].Key)
+}
+
+func TestValidateMessage(t *testing.T) {
+	key := "{count, plural, one {# item} other {# items}}"
+	assert.NoError(t, ValidateMessage(key, "{count, plural, one {# artículo} other {# artículos}}"))
+	assert.Error(t, ValidateMessage(key, "{count, plural, one {# artículo} otro {# artículos}}"))
+	assert.Error(t, ValidateMessage(key, "{count, plural, one {# artículo}"))
+
+	// keys that aren't messages with arguments aren't checked
+	assert.NoError(t, ValidateMessage("Use {x, y} syntax", "Usá la sintaxis {x, y"))
+	assert.NoError(t, ValidateMessage("Hello %s", "Hola {"))
+}
@@ -0,0 +1,122 @@
+package babel
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sort"
+
+	"github.com/leonelquinteros/gotext"
+	"golang.org/x/text/language"
+)
+
+// messages are the translations of a locale. Next to the gotext translator,
+// used to translate and pluralize, it keeps the parsed entries so a lookup
+// can tell a missing key from a translation identical to its key, such as
+// "Total" or "Email" in most languages.
+type messages struct {
+	*gotext.Po
+
+	entries  map[string]*gotext.Translation
+	contexts map[string]map[string]*gotext.Translation
+
+	// content is the .po content of every file of the locale, to add the
+	// files loaded later to.
+	content []byte
+
+	// icu holds the translations parsed as ICU messages, for Trf, and
+	// invalid the translations of ICU keys that failed to parse.
+	icu     map[string]icuMessage
+	invalid []InvalidMessage
+}
+
+// newMessages parses the .po content of the locale.
+func newMessages(locale language.Tag, content []byte) (*messages, error) {
+	po := new(gotext.Po)
+	po.Parse(withPluralForms(locale, content))
+
+	// gotext keeps the parsed entries to itself, but hands them out encoded
+	binary, err := po.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var encoding gotext.TranslatorEncoding
+	if err := gob.NewDecoder(bytes.NewReader(binary)).Decode(&encoding); err != nil {
+		return nil, err
+	}
+
+	m := &messages{Po: po, entries: encoding.Translations, contexts: encoding.Contexts, content: content}
+	m.parseMessages(locale)
+	return m, nil
+}
+
+// parseMessages parses the translations as ICU messages, keeping the valid
+// ones and the errors of the translations of ICU keys.
+func (m *messages) parseMessages(locale language.Tag) {
+	m.icu = make(map[string]icuMessage, len(m.entries))
+	for key, entry := range m.entries {
+		if key == "" || !m.has("", key) {
+			continue
+		}
+		message, err := parseMessage(entry.Get())
+		if err == nil {
+			m.icu[key] = message
+		} else if isMessageKey(key) {
+			m.invalid = append(m.invalid, InvalidMessage{Locale: locale, Key: key, Err: err})
+		}
+	}
+	sort.Slice(m.invalid, func(i, j int) bool { return m.invalid[i].Key < m.invalid[j].Key })
+}
+
+// has reports whether the key, within the context when not empty, has a
+// translation.
+func (m *messages) has(context string, key string) bool {
+	entries := m.entries
+	if context != "" {
+		entries = m.contexts[context]
+	}
+
+	entry, ok := entries[key]
+	if !ok {
+		return false
+	}
+	for _, str := range entry.Trs {
+		if str != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeContents joins the .po content of a file to the content of the files
+// loaded before it for the same locale. Its entries override theirs, but
+// there is a single header: the first one declaring Plural-Forms, or else
+// the first one.
+func mergeContents(loaded []byte, content []byte) []byte {
+	if header := poHeader.Find(content); header != nil {
+		loadedHeader := poHeader.Find(loaded)
+		if loadedHeader == nil ||
+			!bytes.Contains(loadedHeader, pluralFormsHeader) && bytes.Contains(header, pluralFormsHeader) {
+			loaded = joinContents(header, withoutHeader(loaded))
+		}
+		content = withoutHeader(content)
+	}
+	return joinContents(loaded, content)
+}
+
+// withoutHeader returns the .po content without its header entry.
+func withoutHeader(content []byte) []byte {
+	header := poHeader.FindIndex(content)
+	if header == nil {
+		return content
+	}
+	return joinContents(content[:header[0]], content[header[1]:])
+}
+
+// joinContents returns a new .po content with the entries of a followed by
+// the ones of b.
+func joinContents(a []byte, b []byte) []byte {
+	joined := make([]byte, 0, len(a)+len(b)+1)
+	joined = append(joined, a...)
+	joined = append(joined, '\n')
+	return append(joined, b...)
+}
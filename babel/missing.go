@@ -0,0 +1,211 @@
+package babel
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// Miss describes a lookup that found no translation and fell back to the raw
+// key.
+type Miss struct {
+	Locale  language.Tag
+	Context string
+	Key     string
+	// Plural is the plural key of Trn and Trnc lookups.
+	Plural string
+	// Caller is the file:line of the call that missed, empty if unknown.
+	Caller string
+}
+
+// MissingHandler is called with every lookup that misses. It runs on the
+// goroutine of the lookup, so it must be safe for concurrent use and fast.
+type MissingHandler func(Miss)
+
+// missingHandler wraps the handler so it can be stored in an atomic.Value.
+type missingHandler struct {
+	handle MissingHandler
+}
+
+// OnMissing sets the handler called when a lookup of the translator misses.
+// A nil handler disables the reporting.
+func (t *Translator) OnMissing(handler MissingHandler) {
+	t.onMissing.Store(missingHandler{handle: handler})
+}
+
+// missing reports a miss to the handler, if any. The caller is only looked
+// up when there is a handler to report to.
+func (t *Translator) missing(locale language.Tag, context, key, plural string) {
+	handler, _ := t.onMissing.Load().(missingHandler)
+	if handler.handle == nil {
+		return
+	}
+	handler.handle(Miss{Locale: locale, Context: context, Key: key, Plural: plural, Caller: caller()})
+}
+
+// caller returns the position of the first call outside of this package.
+func caller() string {
+	pcs := make([]uintptr, 10)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// packagePrefix is the prefix of the function names of this package.
+var packagePrefix = strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(format).Pointer()).Name(), "format")
+
+// MissingHandlers returns a handler that calls every given handler in order.
+func MissingHandlers(handlers ...MissingHandler) MissingHandler {
+	return func(miss Miss) {
+		for _, handle := range handlers {
+			handle(miss)
+		}
+	}
+}
+
+// LogMissing returns a handler that logs every miss with logf, for example
+// log.Printf.
+func LogMissing(logf func(format string, args ...interface{})) MissingHandler {
+	return func(miss Miss) {
+		logf("babel: missing translation [locale:%s][context:%q][key:%q][caller:%s]", miss.Locale, miss.Context, miss.Key, miss.Caller)
+	}
+}
+
+// CountMissing returns a handler that calls increment with the locale of
+// every miss, to feed a metric without the unbounded cardinality of the keys.
+func CountMissing(increment func(locale language.Tag)) MissingHandler {
+	return func(miss Miss) {
+		increment(miss.Locale)
+	}
+}
+
+// MissingCollector deduplicates the misses reported to its Handle method,
+// remembering every locale and caller of each key.
+type MissingCollector struct {
+	mu     sync.Mutex
+	misses map[string]*collectedMiss
+}
+
+type collectedMiss struct {
+	context, key, plural string
+	locales              map[string]bool
+	callers              map[string]bool
+}
+
+// NewMissingCollector returns an empty collector.
+func NewMissingCollector() *MissingCollector {
+	return &MissingCollector{misses: map[string]*collectedMiss{}}
+}
+
+// Handle records the miss. It is meant to be used as a MissingHandler.
+func (c *MissingCollector) Handle(miss Miss) {
+	id := miss.Context + "\x04" + miss.Key
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	collected, ok := c.misses[id]
+	if !ok {
+		collected = &collectedMiss{
+			context: miss.Context,
+			key:     miss.Key,
+			locales: map[string]bool{},
+			callers: map[string]bool{},
+		}
+		c.misses[id] = collected
+	}
+	if miss.Plural != "" {
+		collected.plural = miss.Plural
+	}
+	collected.locales[miss.Locale.String()] = true
+	if miss.Caller != "" {
+		collected.callers[miss.Caller] = true
+	}
+}
+
+// Len returns the number of distinct keys collected.
+func (c *MissingCollector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.misses)
+}
+
+// Reset forgets every collected miss.
+func (c *MissingCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.misses = map[string]*collectedMiss{}
+}
+
+// WritePo writes the collected keys as a .po fragment, sorted by key, with
+// the callers as references and the locales that missed them as comments.
+func (c *MissingCollector) WritePo(w io.Writer) error {
+	c.mu.Lock()
+	misses := make([]collectedMiss, 0, len(c.misses))
+	for _, miss := range c.misses {
+		misses = append(misses, *miss)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(misses, func(i, j int) bool {
+		if misses[i].key != misses[j].key {
+			return misses[i].key < misses[j].key
+		}
+		return misses[i].context < misses[j].context
+	})
+
+	for i, miss := range misses {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "#. missing in %s\n", strings.Join(sortedKeys(miss.locales), ", "))
+		for _, caller := range sortedKeys(miss.callers) {
+			fmt.Fprintf(&b, "#: %s\n", caller)
+		}
+		if miss.context != "" {
+			fmt.Fprintf(&b, "msgctxt %s\n", quote(miss.context))
+		}
+		fmt.Fprintf(&b, "msgid %s\n", quote(miss.key))
+		if miss.plural != "" {
+			fmt.Fprintf(&b, "msgid_plural %s\nmsgstr[0] \"\"\nmsgstr[1] \"\"\n", quote(miss.plural))
+		} else {
+			b.WriteString("msgstr \"\"\n")
+		}
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+func quote(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
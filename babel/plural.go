@@ -0,0 +1,111 @@
+package babel
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+const defaultPluralForms = "nplurals=2; plural=(n != 1);"
+
+// pluralForms holds the CLDR cardinal plural rules written as gettext
+// Plural-Forms expressions. They are used for the bundles that don't declare
+// their own Plural-Forms header.
+var pluralForms = map[string]string{
+	// one form only
+	"id": "nplurals=1; plural=0;",
+	"ja": "nplurals=1; plural=0;",
+	"ko": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;",
+	"th": "nplurals=1; plural=0;",
+	"vi": "nplurals=1; plural=0;",
+	"zh": "nplurals=1; plural=0;",
+
+	// one: n = 1
+	"ca":    "nplurals=2; plural=(n != 1);",
+	"da":    "nplurals=2; plural=(n != 1);",
+	"de":    "nplurals=2; plural=(n != 1);",
+	"el":    "nplurals=2; plural=(n != 1);",
+	"en":    "nplurals=2; plural=(n != 1);",
+	"es":    "nplurals=2; plural=(n != 1);",
+	"fi":    "nplurals=2; plural=(n != 1);",
+	"gl":    "nplurals=2; plural=(n != 1);",
+	"hu":    "nplurals=2; plural=(n != 1);",
+	"it":    "nplurals=2; plural=(n != 1);",
+	"nb":    "nplurals=2; plural=(n != 1);",
+	"nl":    "nplurals=2; plural=(n != 1);",
+	"pt-PT": "nplurals=2; plural=(n != 1);",
+	"sv":    "nplurals=2; plural=(n != 1);",
+	"tr":    "nplurals=2; plural=(n != 1);",
+
+	// one: i = 0,1
+	"fr": "nplurals=2; plural=(n > 1);",
+	"pt": "nplurals=2; plural=(n > 1);",
+
+	// one, few, many
+	"cs": "nplurals=3; plural=(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2);",
+	"sk": "nplurals=3; plural=(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2);",
+	"pl": "nplurals=3; plural=(n == 1 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n == 1 ? 0 : n == 0 || (n%100 >= 1 && n%100 <= 19) ? 1 : 2);",
+
+	// zero, one, two, few, many
+	"ar": "nplurals=6; plural=(n == 0 ? 0 : n == 1 ? 1 : n == 2 ? 2 : n%100 >= 3 && n%100 <= 10 ? 3 : n%100 >= 11 ? 4 : 5);",
+}
+
+// pluralRule returns the gettext Plural-Forms rule for the given locale,
+// trying the locale and its parents before the base language.
+func pluralRule(locale language.Tag) string {
+	for tag := locale; tag != language.Und; tag = tag.Parent() {
+		if rule, ok := pluralForms[tag.String()]; ok {
+			return rule
+		}
+	}
+	if base, confidence := locale.Base(); confidence != language.No {
+		if rule, ok := pluralForms[base.String()]; ok {
+			return rule
+		}
+	}
+	return defaultPluralForms
+}
+
+// poHeader matches the header entry of a .po content, the entry with an
+// empty msgid, with every line of its msgstr.
+var poHeader = regexp.MustCompile(`(?m)^msgid ""[ \t]*\r?\nmsgstr "(?:[^"\\\n]|\\.)*"[ \t]*\r?\n(?:"(?:[^"\\\n]|\\.)*"[ \t]*\r?\n)*`)
+
+// pluralFormsHeader names the Plural-Forms line of a header entry.
+var pluralFormsHeader = []byte("Plural-Forms:")
+
+// withPluralForms adds the CLDR Plural-Forms header for the locale to a .po
+// content, unless the file already declares its own. gotext only reads the
+// headers from the header entry, so the line goes at the end of the existing
+// one, or into a new header entry when the file has none.
+func withPluralForms(locale language.Tag, content []byte) []byte {
+	line := fmt.Sprintf("\"Plural-Forms: %s\\n\"\n", pluralRule(locale))
+
+	if header := poHeader.FindIndex(content); header != nil {
+		if bytes.Contains(content[header[0]:header[1]], pluralFormsHeader) {
+			return content
+		}
+		withHeader := make([]byte, 0, len(content)+len(line))
+		withHeader = append(withHeader, content[:header[1]]...)
+		withHeader = append(withHeader, line...)
+		return append(withHeader, content[header[1]:]...)
+	}
+
+	header := "msgid \"\"\nmsgstr \"\"\n" + line + "\n"
+	return append([]byte(header), content...)
+}
+
+// isSingular reports whether count takes the CLDR "one" form in the locale.
+// It is used to pick between the untranslated singular and plural keys.
+func isSingular(locale language.Tag, count int) bool {
+	if count < 0 {
+		count = -count
+	}
+	return plural.Cardinal.MatchPlural(locale, count, 0, 0, 0, 0) == plural.One
+}
@@ -0,0 +1,148 @@
+package babel
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// source is a bundle loaded with Load, LoadDir, LoadReader or LoadFS,
+// remembered so it can be read again by Reload.
+type source struct {
+	path string
+	dir  bool
+
+	reader io.ReaderAt
+	size   int64
+
+	fs http.FileSystem
+}
+
+func (s source) load(translations map[string]*messages) error {
+	switch {
+	case s.reader != nil:
+		return loadZipReader(s.reader, s.size, translations)
+	case s.fs != nil:
+		return loadFS(s.fs, translations)
+	case s.dir:
+		return loadDir(s.path, translations)
+	default:
+		return loadZip(s.path, translations)
+	}
+}
+
+// addSource loads a new bundle on top of the current translations.
+func (t *Translator) addSource(src source) error {
+	t.loading.Lock()
+	defer t.loading.Unlock()
+
+	c := t.snapshot()
+	translations := make(map[string]*messages, len(c.translations))
+	for locale, translation := range c.translations {
+		translations[locale] = translation
+	}
+	if err := src.load(translations); err != nil {
+		return err
+	}
+
+	t.sources = append(t.sources, src)
+	t.current.Store(newCatalog(translations, c.defaultLocale))
+	t.reportInvalid(translations, c.translations)
+	return nil
+}
+
+// Reload reads again every loaded bundle and swaps the
+// new translations in at once. Lookups running meanwhile keep using the
+// previous translations, which are also kept if any bundle fails to load.
+func (t *Translator) Reload() error {
+	t.loading.Lock()
+	defer t.loading.Unlock()
+
+	translations := map[string]*messages{}
+	for _, src := range t.sources {
+		if err := src.load(translations); err != nil {
+			return err
+		}
+	}
+
+	t.current.Store(newCatalog(translations, t.snapshot().defaultLocale))
+	t.reportInvalid(translations, nil)
+	return nil
+}
+
+// Watch checks the loaded bundles every interval and calls Reload when a .zip
+// bundle or a file inside a bundle directory changes on disk. Bundles
+// loaded from memory or from an http.FileSystem are not watched. Reload
+// errors are passed to onError, when given, and retried on the next check.
+// The returned function stops the watcher.
+func (t *Translator) Watch(interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	// taken before returning, so that the changes made from then on are seen
+	last := t.fingerprint()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				now := t.fingerprint()
+				if now == last {
+					continue
+				}
+				if err := t.Reload(); err != nil {
+					if onError != nil {
+						onError(err)
+					}
+					continue
+				}
+				last = now
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// fingerprint summarizes the size and modification time of every loaded
+// bundle file, so that any change on disk produces a different value.
+func (t *Translator) fingerprint() string {
+	t.loading.Lock()
+	watched := append([]source(nil), t.sources...)
+	t.loading.Unlock()
+
+	var b strings.Builder
+	stamp := func(path string, info os.FileInfo) {
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	for _, src := range watched {
+		if src.path == "" {
+			continue
+		}
+		if !src.dir {
+			if info, err := os.Stat(src.path); err == nil {
+				stamp(src.path, info)
+			}
+			continue
+		}
+		filepath.Walk(src.path, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && IsBundleFile(path) {
+				stamp(path, info)
+			}
+			return nil
+		})
+	}
+	return b.String()
+}
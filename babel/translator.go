@@ -0,0 +1,190 @@
+package babel
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/language"
+)
+
+// Translator holds its own set of translation bundles. Translators can be
+// layered: a translator created with bases looks up in its own bundles first,
+// so they override the ones loaded in the bases.
+type Translator struct {
+	bases []*Translator
+
+	// loading serializes every change to the loaded translations. Lookups
+	// don't take it, they read the current catalog atomically.
+	loading sync.Mutex
+	sources []source
+	current atomic.Value
+
+	onMissing atomic.Value
+	onInvalid atomic.Value
+}
+
+// NewTranslator returns an empty translator that falls back to the given
+// bases, in order, for the keys it can't translate.
+func NewTranslator(bases ...*Translator) *Translator {
+	t := &Translator{bases: bases}
+	t.current.Store(newCatalog(map[string]*messages{}, language.Und))
+	return t
+}
+
+func (t *Translator) snapshot() *catalog {
+	return t.current.Load().(*catalog)
+}
+
+// Load adds the translations of a .zip bundle to the translator.
+func (t *Translator) Load(bundle string) error {
+	return t.addSource(source{path: bundle})
+}
+
+// LoadDir adds the translations of a directory with a subdirectory of .po
+// files per locale to the translator.
+func (t *Translator) LoadDir(bundleDir string) error {
+	return t.addSource(source{path: bundleDir, dir: true})
+}
+
+// LoadReader adds the translations of a .zip bundle of the given size, read
+// from memory or from any other io.ReaderAt, to the translator.
+func (t *Translator) LoadReader(bundle io.ReaderAt, size int64) error {
+	return t.addSource(source{reader: bundle, size: size})
+}
+
+// LoadFS adds the translations of a file system with a directory of .po files
+// per locale, such as an embedded assets package, to the translator.
+func (t *Translator) LoadFS(fs http.FileSystem) error {
+	return t.addSource(source{fs: fs})
+}
+
+// SetDefaultLocale sets the locale used when neither the requested locale nor
+// any of its parents or regional variants has been loaded. It is meant to be
+// called once, before Load or LoadDir.
+func (t *Translator) SetDefaultLocale(locale language.Tag) {
+	t.loading.Lock()
+	defer t.loading.Unlock()
+
+	t.current.Store(newCatalog(t.snapshot().translations, locale))
+}
+
+// Tr translates key to the given locale, formatting it with args.
+func (t *Translator) Tr(locale language.Tag, key string, args ...interface{}) string {
+	text, ok := t.lookup(locale, func(translation *messages) (string, bool) {
+		return translation.Get(key), translation.has("", key)
+	})
+	if !ok {
+		t.missing(locale, "", key, "")
+		text = key
+	}
+	return format(text, args...)
+}
+
+// Trn translates the singular or plural key, chosen by count with the plural
+// rule of the locale, formatting it with args.
+func (t *Translator) Trn(locale language.Tag, count int, singular string, plural string, args ...interface{}) string {
+	text, ok := t.lookup(locale, func(translation *messages) (string, bool) {
+		return translation.GetN(singular, plural, count), translation.has("", singular)
+	})
+	if !ok {
+		t.missing(locale, "", singular, plural)
+		text = plural
+		if isSingular(locale, count) {
+			text = singular
+		}
+	}
+	return format(text, args...)
+}
+
+// Trc translates key to the given locale within a message context, used to
+// tell apart identical keys that translate differently.
+func (t *Translator) Trc(locale language.Tag, context string, key string, args ...interface{}) string {
+	text, ok := t.lookup(locale, func(translation *messages) (string, bool) {
+		return translation.GetC(key, context), translation.has(context, key)
+	})
+	if !ok {
+		t.missing(locale, context, key, "")
+		text = key
+	}
+	return format(text, args...)
+}
+
+// Trnc is the plural form of Trc.
+func (t *Translator) Trnc(locale language.Tag, context string, count int, singular string, plural string, args ...interface{}) string {
+	text, ok := t.lookup(locale, func(translation *messages) (string, bool) {
+		return translation.GetNC(singular, plural, count, context), translation.has(context, singular)
+	})
+	if !ok {
+		t.missing(locale, context, singular, plural)
+		text = plural
+		if isSingular(locale, count) {
+			text = singular
+		}
+	}
+	return format(text, args...)
+}
+
+// Locales returns the locales loaded in the translator and its bases.
+func (t *Translator) Locales() []language.Tag {
+	var locales []language.Tag
+	seen := map[string]bool{}
+	for _, layer := range t.layers() {
+		for _, tag := range layer.snapshot().supported {
+			if !seen[tag.String()] {
+				seen[tag.String()] = true
+				locales = append(locales, tag)
+			}
+		}
+	}
+	return locales
+}
+
+// Supports reports whether the translator, or any of its bases, has loaded
+// the locale, one of its parents or a close regional variant of it.
+func (t *Translator) Supports(locale language.Tag) bool {
+	for _, layer := range t.layers() {
+		if len(layer.snapshot().fallbacks(locale)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup walks the fallback chain of the locale through every layer, and
+// then the default locale of every layer, until get finds a translation.
+func (t *Translator) lookup(locale language.Tag, get func(*messages) (string, bool)) (string, bool) {
+	layers := t.layers()
+
+	for _, layer := range layers {
+		for _, translation := range layer.snapshot().fallbacks(locale) {
+			if text, ok := get(translation); ok {
+				return text, true
+			}
+		}
+	}
+
+	for _, layer := range layers {
+		c := layer.snapshot()
+		if c.defaultLocale == language.Und {
+			continue
+		}
+		for _, translation := range c.fallbacks(c.defaultLocale) {
+			if text, ok := get(translation); ok {
+				return text, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// layers returns the translator followed by its bases, depth first.
+func (t *Translator) layers() []*Translator {
+	layers := []*Translator{t}
+	for _, base := range t.bases {
+		layers = append(layers, base.layers()...)
+	}
+	return layers
+}
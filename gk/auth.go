@@ -0,0 +1,155 @@
+package gk
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/libs/go/errors"
+)
+
+// HasScope reports whether the caller was granted the scope.
+func (c Caller) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAnyScope is a middleware that only lets through the callers granted
+// at least one of the scopes. It returns status 403 otherwise.
+func RequireAnyScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := requestCaller(c.Request)
+		for _, scope := range scopes {
+			if caller.HasScope(scope) {
+				c.Next()
+				return
+			}
+		}
+
+		forbidden(c, "missing scope", "caller requires any of the scopes", map[string]string{
+			"scopes": strings.Join(scopes, ","),
+		})
+	}
+}
+
+// RequireAllScopes is a middleware that only lets through the callers granted
+// every one of the scopes. It returns status 403 otherwise, listing the
+// missing scopes.
+func RequireAllScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := requestCaller(c.Request)
+
+		var missing []string
+		for _, scope := range scopes {
+			if !caller.HasScope(scope) {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) > 0 {
+			forbidden(c, "missing scope", "caller requires all of the scopes", map[string]string{
+				"scopes": strings.Join(missing, ","),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdmin is a middleware that only lets through the admin callers. It
+// returns status 403 otherwise.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requestCaller(c.Request).IsAdmin {
+			forbidden(c, "caller is not admin", "caller requires admin permissions", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePrivate is a middleware that rejects the public callers, those
+// calling from outside of the internal network, with status 403.
+func RequirePrivate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requestCaller(c.Request).IsPublic {
+			forbidden(c, "caller is public", "endpoint is not available to public callers", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ownerSettings contains the exceptions of the RequireOwner middleware.
+type ownerSettings struct {
+	AllowAdmin bool
+}
+
+// OwnerOpt is a function for the RequireOwner middleware, used for overriding
+// its default settings.
+type OwnerOpt func(*ownerSettings)
+
+// AllowAdmin lets the admin callers through RequireOwner, whatever their ID.
+func AllowAdmin() OwnerOpt {
+	return func(s *ownerSettings) {
+		s.AllowAdmin = true
+	}
+}
+
+// RequireOwner is a middleware that only lets through the callers whose ID is
+// the value of the path parameter, such as the user_id of
+// /users/:user_id/items. Admin callers are not exempt unless AllowAdmin is
+// given. It returns status 401 when the caller is not identified and 403
+// when it is not the owner.
+func RequireOwner(param string, opts ...OwnerOpt) gin.HandlerFunc {
+	settings := ownerSettings{}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return func(c *gin.Context) {
+		caller := requestCaller(c.Request)
+		if settings.AllowAdmin && caller.IsAdmin {
+			c.Next()
+			return
+		}
+
+		if caller.ID == 0 {
+			errors.ReturnError(c, &errors.Error{
+				Code:    errors.AuthorizationApiError,
+				Cause:   "missing caller",
+				Message: "invalid caller.id",
+			})
+			c.Abort()
+			return
+		}
+
+		value := c.Param(param)
+		if ownerID, err := strconv.ParseUint(value, 10, 64); err != nil || ownerID != caller.ID {
+			forbidden(c, "caller is not owner", "caller does not own the resource", map[string]string{
+				"caller.id": strconv.FormatUint(caller.ID, 10),
+				param:       value,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// forbidden responds with status 403 and aborts the request.
+func forbidden(c *gin.Context, cause string, message string, values map[string]string) {
+	errors.ReturnError(c, &errors.Error{
+		Code:    errors.ForbiddenApiError,
+		Cause:   cause,
+		Message: message,
+		Values:  values,
+	})
+	c.Abort()
+}
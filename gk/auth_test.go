@@ -0,0 +1,68 @@
+package gk_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/gk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorization(t *testing.T) {
+	tt := []struct {
+		name       string
+		middleware gin.HandlerFunc
+		path       string
+		headers    map[string]string
+		status     int
+	}{
+		{"any scope", gk.RequireAnyScope("read", "write"), "/users/1",
+			map[string]string{"X-Caller-Scopes": "write"}, http.StatusOK},
+		{"any scope missing", gk.RequireAnyScope("read", "write"), "/users/1",
+			map[string]string{"X-Caller-Scopes": "delete"}, http.StatusForbidden},
+		{"all scopes", gk.RequireAllScopes("read", "write"), "/users/1",
+			map[string]string{"X-Caller-Scopes": "read,write"}, http.StatusOK},
+		{"all scopes missing", gk.RequireAllScopes("read", "write"), "/users/1",
+			map[string]string{"X-Caller-Scopes": "read"}, http.StatusForbidden},
+		{"admin", gk.RequireAdmin(), "/users/1",
+			map[string]string{"X-Caller-Scopes": "admin"}, http.StatusOK},
+		{"not admin", gk.RequireAdmin(), "/users/1",
+			map[string]string{"X-Caller-Scopes": "read"}, http.StatusForbidden},
+		{"private", gk.RequirePrivate(), "/users/1",
+			map[string]string{"X-Public": "false"}, http.StatusOK},
+		{"public", gk.RequirePrivate(), "/users/1",
+			map[string]string{"X-Public": "true"}, http.StatusForbidden},
+		{"owner", gk.RequireOwner("user_id"), "/users/120120120",
+			map[string]string{"X-Caller-Id": "120120120"}, http.StatusOK},
+		{"owner admin", gk.RequireOwner("user_id", gk.AllowAdmin()), "/users/1",
+			map[string]string{"X-Caller-Id": "120120120", "X-Caller-Scopes": "admin"}, http.StatusOK},
+		{"owner admin not allowed", gk.RequireOwner("user_id"), "/users/1",
+			map[string]string{"X-Caller-Id": "120120120", "X-Caller-Scopes": "admin"}, http.StatusForbidden},
+		{"not owner", gk.RequireOwner("user_id"), "/users/1",
+			map[string]string{"X-Caller-Id": "120120120"}, http.StatusForbidden},
+		{"invalid owner", gk.RequireOwner("user_id"), "/users/me",
+			map[string]string{"X-Caller-Id": "120120120"}, http.StatusForbidden},
+		{"no caller", gk.RequireOwner("user_id"), "/users/1",
+			map[string]string{}, http.StatusUnauthorized},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/users/:user_id", tc.middleware, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
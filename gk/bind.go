@@ -0,0 +1,170 @@
+package gk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/libs/go/errors"
+)
+
+// rootField is the field of the errors that concern the whole body, as
+// named by the JSON schema validation.
+const rootField = "(root)"
+
+// Validator is implemented by the request structs that check more than the
+// JSON schema can express. Validate returns FieldErrors to report the
+// invalid fields, any other error concerns the whole body.
+type Validator interface {
+	Validate() error
+}
+
+// FieldErrors maps the JSON path of each invalid field to the description of
+// the error.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	buf := bytes.NewBuffer(nil)
+	for _, field := range fields {
+		fmt.Fprintf(buf, "%s: %s\n", field, e[field])
+	}
+
+	return buf.String()
+}
+
+var (
+	requestSchemasMutex sync.RWMutex
+	requestSchemas      = map[reflect.Type]string{}
+)
+
+// RegisterSchema sets the JSON schema, loaded with jsonschema.AddSchemaDir,
+// that validates the bodies bound by BodyHandler to the type of request.
+func RegisterSchema(request interface{}, schemaName string) {
+	requestSchemasMutex.Lock()
+	defer requestSchemasMutex.Unlock()
+
+	requestSchemas[structType(reflect.TypeOf(request))] = schemaName
+}
+
+func requestSchema(t reflect.Type) (string, bool) {
+	requestSchemasMutex.RLock()
+	defer requestSchemasMutex.RUnlock()
+
+	name, ok := requestSchemas[t]
+	return name, ok
+}
+
+func structType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+var (
+	ginContextType = reflect.TypeOf((*gin.Context)(nil))
+	gkContextType  = reflect.TypeOf((*Context)(nil))
+)
+
+// BodyHandler is Handler for the functions that receive the request body
+// decoded into a struct, either by value or by pointer:
+//
+//	func CreateItem(c *gin.Context, ctx *gk.Context, request CreateItemRequest)
+//
+// The body is validated against the JSON schema registered for the struct
+// with RegisterSchema, if any, decoded, and validated by the struct itself
+// when it implements Validator. The function is only called when all of it
+// succeeds, the request fails with status 422 and the invalid fields
+// otherwise. BodyHandler panics when f is not such a function.
+func BodyHandler(f interface{}, opts ...HandlerOpt) gin.HandlerFunc {
+	fn := reflect.ValueOf(f)
+	ft := fn.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 3 || ft.NumOut() != 0 ||
+		ft.In(0) != ginContextType || ft.In(1) != gkContextType ||
+		structType(ft.In(2)).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("gk: BodyHandler expects a func(*gin.Context, *gk.Context, T) with T a struct or a pointer to one, got %s", ft))
+	}
+
+	requestType := ft.In(2)
+	pointer := requestType.Kind() == reflect.Ptr
+
+	return handler(funcName(f), func(c *gin.Context, ctx *Context) {
+		request, ok := bindBody(c, structType(requestType))
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		if !pointer {
+			request = request.Elem()
+		}
+		fn.Call([]reflect.Value{reflect.ValueOf(c), reflect.ValueOf(ctx), request})
+	}, opts...)
+}
+
+// bindBody decodes and validates the request body into a new value of type t,
+// returning a pointer to it. It responds with the error and returns false
+// when the body is not valid.
+func bindBody(c *gin.Context, t reflect.Type) (reflect.Value, bool) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errors.ReturnError(c, &errors.Error{
+			Code:    errors.InternalServerApiError,
+			Message: "Error reading JSON body from request",
+			Cause:   err.Error(),
+		})
+		return reflect.Value{}, false
+	}
+	c.Request.Body.Close()
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if schemaName, ok := requestSchema(t); ok {
+		if !validateBody(c, schemaName, body) {
+			return reflect.Value{}, false
+		}
+	}
+
+	request := reflect.New(t)
+	if err := json.Unmarshal(body, request.Interface()); err != nil {
+		returnValidationError(c, "Error decoding JSON body", decodeErrors(err))
+		return reflect.Value{}, false
+	}
+
+	if validator, ok := request.Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			returnValidationError(c, "Error validating body", validatorErrors(err))
+			return reflect.Value{}, false
+		}
+	}
+
+	return request, true
+}
+
+// decodeErrors returns the per-field errors of a json.Unmarshal error.
+func decodeErrors(err error) map[string]string {
+	if terr, ok := err.(*json.UnmarshalTypeError); ok && terr.Field != "" {
+		return map[string]string{
+			terr.Field: fmt.Sprintf("Invalid type. Expected: %s, given: %s", terr.Type, terr.Value),
+		}
+	}
+	return map[string]string{rootField: err.Error()}
+}
+
+// validatorErrors returns the per-field errors of a Validator error.
+func validatorErrors(err error) map[string]string {
+	if fields, ok := err.(FieldErrors); ok {
+		return fields
+	}
+	return map[string]string{rootField: err.Error()}
+}
@@ -0,0 +1,170 @@
+package gk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/gk"
+	"github.com/mercadolibre/coreservices-team/gk/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+type itemRequest struct {
+	Title    string `json:"title"`
+	Quantity int    `json:"quantity"`
+}
+
+func (r itemRequest) Validate() error {
+	if r.Title == "forbidden" {
+		return gk.FieldErrors{"title": "Title is not allowed"}
+	}
+	if r.Quantity > 100 {
+		return errors.New("too many items")
+	}
+	return nil
+}
+
+const itemSchema = `{
+	"type": "object",
+	"required": ["title"],
+	"properties": {
+		"title": {"type": "string"},
+		"quantity": {"type": "integer", "minimum": 1}
+	}
+}`
+
+func TestBodyHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "item.json"), []byte(itemSchema), 0644))
+	assert.NoError(t, jsonschema.AddSchemaDir(dir))
+	gk.RegisterSchema(itemRequest{}, "item.json")
+
+	tt := []struct {
+		name   string
+		body   string
+		status int
+		values map[string]string
+	}{
+		{"valid", `{"title": "book", "quantity": 2}`, http.StatusOK, nil},
+		{"schema", `{"quantity": 0}`, http.StatusUnprocessableEntity, map[string]string{
+			"title":    "title is required",
+			"quantity": "Must be greater than or equal to 1",
+		}},
+		{"malformed", `{"title": `, http.StatusUnprocessableEntity, map[string]string{
+			"(root)": "unexpected end of JSON input",
+		}},
+		{"field validator", `{"title": "forbidden", "quantity": 1}`, http.StatusUnprocessableEntity, map[string]string{
+			"title": "Title is not allowed",
+		}},
+		{"validator", `{"title": "book", "quantity": 101}`, http.StatusUnprocessableEntity, map[string]string{
+			"(root)": "too many items",
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(tc.body))
+
+			called := false
+			gk.BodyHandler(func(c *gin.Context, ctx *gk.Context, request itemRequest) {
+				called = true
+				assert.Equal(t, itemRequest{Title: "book", Quantity: 2}, request)
+
+				// The body remains readable by the handler
+				body, _ := ioutil.ReadAll(c.Request.Body)
+				assert.Equal(t, tc.body, string(body))
+				c.Status(http.StatusOK)
+			})(c)
+
+			assert.Equal(t, tc.status, rr.Code)
+			assert.Equal(t, tc.status == http.StatusOK, called)
+			if tc.values != nil {
+				var response struct {
+					Error  string            `json:"error"`
+					Values map[string]string `json:"values"`
+				}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, "UnprocessableEntityApiError", response.Error)
+				assert.Equal(t, tc.values, response.Values)
+			}
+		})
+	}
+}
+
+func TestBodyHandlerPointer(t *testing.T) {
+	type decodeRequest struct {
+		Count int `json:"count"`
+	}
+
+	tt := []struct {
+		name   string
+		body   string
+		status int
+		values map[string]string
+	}{
+		{"valid", `{"count": 3}`, http.StatusOK, nil},
+		{"type", `{"count": "three"}`, http.StatusUnprocessableEntity, map[string]string{
+			"count": "Invalid type. Expected: int, given: string",
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(tc.body))
+
+			gk.BodyHandler(func(c *gin.Context, ctx *gk.Context, request *decodeRequest) {
+				assert.Equal(t, 3, request.Count)
+				c.Status(http.StatusOK)
+			})(c)
+
+			assert.Equal(t, tc.status, rr.Code)
+			if tc.values != nil {
+				var response struct {
+					Values map[string]string `json:"values"`
+				}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.values, response.Values)
+			}
+		})
+	}
+}
+
+func TestBodyHandlerSignature(t *testing.T) {
+	assert.Panics(t, func() {
+		gk.BodyHandler(func(c *gin.Context, ctx *gk.Context) {})
+	})
+	assert.Panics(t, func() {
+		gk.BodyHandler(func(c *gin.Context, ctx *gk.Context, count int) {})
+	})
+}
+
+func TestBodyHandlerMissingSchema(t *testing.T) {
+	type missingRequest struct {
+		Name string `json:"name"`
+	}
+	gk.RegisterSchema(missingRequest{}, "missing.json")
+
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(`{"name": "book"}`))
+
+	gk.BodyHandler(func(c *gin.Context, ctx *gk.Context, request missingRequest) {
+		c.Status(http.StatusOK)
+	})(c)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
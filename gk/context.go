@@ -1,17 +1,21 @@
 package gk
 
 import (
+	"context"
 	"github.com/newrelic/go-agent/v3/newrelic"
+	"net/http"
 	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/babel"
 	"github.com/mercadolibre/coreservices-team/libs/go/logger"
 	"github.com/mercadolibre/go-meli-toolkit/mlauth"
 	"github.com/newrelic/go-agent/v3/integrations/nrgin"
 	"github.com/satori/go.uuid"
+	"golang.org/x/text/language"
 )
 
 // Measurable is the interface of the exposed methods used for measuring
@@ -37,6 +41,19 @@ type Caller struct {
 	Scopes   []string
 }
 
+// requestCaller returns the caller of the request, as set by the auth headers.
+func requestCaller(r *http.Request) Caller {
+	// If we can't parse callerID then it remains 0
+	callerID, _ := strconv.ParseUint(mlauth.GetCaller(r), 10, 64)
+
+	return Caller{
+		ID:       callerID,
+		IsAdmin:  mlauth.IsCallerAdmin(r),
+		IsPublic: mlauth.IsPublic(r),
+		Scopes:   mlauth.GetCallerScopes(r),
+	}
+}
+
 // Context contains all the resources we use during a given request
 type Context struct {
 	ClientID  string
@@ -44,7 +61,15 @@ type Context struct {
 	RequestID string
 	Log       *logger.Logger
 
+	// Locale is the locale negotiated by the Locale middleware, translations
+	// should be made with the Tr helpers instead of using it directly.
+	Locale     language.Tag
+	Translator *babel.Translator
+
 	NrTransaction *newrelic.Transaction
+
+	// ctx is derived from the request context, see Context().
+	ctx context.Context
 }
 
 // HandlerFunc defines the signature of our http handlers
@@ -52,44 +77,68 @@ type HandlerFunc func(*gin.Context, *Context)
 
 // Handler receives a MeliHandlerFunc and allows it to be called from inside gin
 // where a gin.HandlerFunc is expected.
-func Handler(f HandlerFunc) gin.HandlerFunc {
-	// Get caller function name so that we can rename newrelic transaction
-	callerName := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+func Handler(f HandlerFunc, opts ...HandlerOpt) gin.HandlerFunc {
+	return handler(funcName(f), f, opts...)
+}
+
+// funcName returns the name of the function f.
+func funcName(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// handler is Handler, callerName being the name of the function the
+// NewRelic transaction is named after.
+func handler(callerName string, f HandlerFunc, opts ...HandlerOpt) gin.HandlerFunc {
+	settings := &handlerSettings{}
+	for _, opt := range opts {
+		opt(settings)
+	}
 
 	return func(c *gin.Context) {
-		rawCallerID := mlauth.GetCaller(c.Request)
 		clientID := mlauth.GetClientId(c.Request)
 
-		// If we can't parse callerID then it remains 0
-		callerID, _ := strconv.ParseUint(rawCallerID, 10, 64)
-
 		reqID := c.GetString("RequestId")
 
-		context := &Context{
-			Caller: Caller{
-				ID:       callerID,
-				IsAdmin:  mlauth.IsCallerAdmin(c.Request),
-				IsPublic: mlauth.IsPublic(c.Request),
-				Scopes:   mlauth.GetCallerScopes(c.Request),
-			},
+		// The locale remains undefined if the Locale middleware is not in use
+		locale, _ := c.Get(localeKey)
+		tag, _ := locale.(language.Tag)
+		translator, _ := c.Get(translatorKey)
+		tr, _ := translator.(*babel.Translator)
+
+		ctx := &Context{
+			Caller:    requestCaller(c.Request),
 			ClientID:  clientID,
 			RequestID: reqID,
 			Log: &logger.Logger{
 				Attributes: logger.Attrs{"request_id": reqID},
 			},
+			Locale:        tag,
+			Translator:    tr,
 			NrTransaction: nrgin.Transaction(c),
 		}
 
 		// Rename NewRelic transaction name to the name of the function that's being
 		// wrapped by our context.
-		if context.NrTransaction != nil {
+		if ctx.NrTransaction != nil {
 			splitURL := strings.Split(callerName, "/")
 			if len(splitURL) > 0 {
-				context.NrTransaction.SetName(splitURL[len(splitURL)-1])
+				ctx.NrTransaction.SetName(splitURL[len(splitURL)-1])
 			}
 		}
 
-		f(c, context)
+		// The request context is cancelled when the client goes away, or when the
+		// route timeout expires, and always once the handler returns.
+		var cancel context.CancelFunc
+		if settings.timeout > 0 {
+			ctx.ctx, cancel = context.WithTimeout(c.Request.Context(), settings.timeout)
+		} else {
+			ctx.ctx, cancel = context.WithCancel(c.Request.Context())
+		}
+		defer cancel()
+		ctx.ctx = ctx.withValues(ctx.ctx)
+		c.Request = c.Request.WithContext(ctx.ctx)
+
+		f(c, ctx)
 	}
 }
 
@@ -126,16 +175,51 @@ func (c *Context) DatastoreSegment(db newrelic.DatastoreProduct, collection stri
 	}
 }
 
+// Tr translates key to the locale of the request.
+func (c *Context) Tr(key string, args ...interface{}) string {
+	return c.translator().Tr(c.Locale, key, args...)
+}
+
+// Trn translates the singular or plural key, chosen by count, to the locale
+// of the request.
+func (c *Context) Trn(count int, singular string, plural string, args ...interface{}) string {
+	return c.translator().Trn(c.Locale, count, singular, plural, args...)
+}
+
+// Trc translates key within a message context to the locale of the request.
+func (c *Context) Trc(context string, key string, args ...interface{}) string {
+	return c.translator().Trc(c.Locale, context, key, args...)
+}
+
+// Trnc is the plural form of Trc.
+func (c *Context) Trnc(context string, count int, singular string, plural string, args ...interface{}) string {
+	return c.translator().Trnc(c.Locale, context, count, singular, plural, args...)
+}
+
+// Trf translates an ICU MessageFormat key to the locale of the request.
+func (c *Context) Trf(key string, args babel.Args) string {
+	return c.translator().Trf(c.Locale, key, args)
+}
+
+func (c *Context) translator() *babel.Translator {
+	if c.Translator == nil {
+		return babel.Default()
+	}
+	return c.Translator
+}
+
 // CreateTestContext returns a MPCS Context ready to use for testing purposes. The
 // context is only populated with a functioning logger and a valid request id.
 // If more information is required, then the user should add it in its end.
 func CreateTestContext() *Context {
 	reqID, _ := uuid.NewV4()
 
-	return &Context{
+	ctx := &Context{
 		RequestID: reqID.String(),
 		Log: &logger.Logger{
 			Attributes: logger.Attrs{"request_id": reqID},
 		},
 	}
+	ctx.ctx = ctx.withValues(context.Background())
+	return ctx
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mercadolibre/coreservices-team/gk"
@@ -43,6 +44,37 @@ func TestHandler(t *testing.T) {
 	})(c)
 }
 
+func TestHandlerContext(t *testing.T) {
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Set("RequestId", "request-id")
+
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.Header.Set("X-Caller-Id", "120120120")
+
+	var requestCtx *gk.Context
+	gk.Handler(func(c *gin.Context, ctx *gk.Context) {
+		requestCtx = ctx
+		deadline, ok := ctx.Context().Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		assert.Equal(t, ctx.Context(), c.Request.Context())
+
+		recovered, ok := gk.FromContext(ctx.Context())
+		assert.True(t, ok)
+		assert.Equal(t, ctx, recovered)
+		assert.Equal(t, "request-id", gk.RequestIDFromContext(ctx.Context()))
+		caller, ok := gk.CallerFromContext(ctx.Context())
+		assert.True(t, ok)
+		assert.EqualValues(t, 120120120, caller.ID)
+		assert.Equal(t, ctx.Log, gk.LoggerFromContext(ctx.Context()))
+		assert.NoError(t, ctx.Context().Err())
+	}, gk.WithTimeout(time.Minute))(c)
+
+	// the context is cancelled once the handler returns
+	assert.Error(t, requestCtx.Context().Err())
+}
+
 func TestCreateTestContext(t *testing.T) {
 	// This test is really unnecessary, but we do it as to not to penalize our code coverage
 	gk.CreateTestContext()
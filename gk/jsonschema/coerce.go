@@ -0,0 +1,183 @@
+package jsonschema
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxRefs bounds the $ref chains followed when looking for a type, so that
+// circular references end.
+const maxRefs = 32
+
+// coercer finds the types of the values among the loaded schema documents,
+// keyed by their file URL and by their $id, following $ref and allOf.
+type coercer map[string]interface{}
+
+// coerceValues builds the document of the values, converting each one to the
+// type of its property in the schema document at reference.
+func coerceValues(documents map[string]interface{}, reference string, values map[string][]string) map[string]interface{} {
+	c := coercer(documents)
+	base, _ := url.Parse(reference)
+	base, schema := c.resolve(base, documents[reference])
+
+	document := make(map[string]interface{}, len(values))
+	for name, list := range values {
+		propertyBase, property := c.property(base, schema, name)
+		if c.schemaType(propertyBase, property) == "array" {
+			itemsBase, items := c.items(propertyBase, property)
+			array := make([]interface{}, len(list))
+			for i, value := range list {
+				array[i] = coerce(c.schemaType(itemsBase, items), value)
+			}
+			document[name] = array
+			continue
+		}
+
+		if len(list) > 0 {
+			document[name] = coerce(c.schemaType(propertyBase, property), list[0])
+		}
+	}
+
+	return document
+}
+
+// resolve follows the $ref of the schema, relative to base, returning the
+// referenced schema and the base of its own references.
+func (c coercer) resolve(base *url.URL, value interface{}) (*url.URL, map[string]interface{}) {
+	schema := object(value)
+	for i := 0; i < maxRefs && base != nil; i++ {
+		if id, ok := schema["$id"].(string); ok {
+			if u, err := base.Parse(id); err == nil {
+				base = u
+			}
+		}
+
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return base, schema
+		}
+		u, err := base.Parse(ref)
+		if err != nil {
+			return base, nil
+		}
+
+		pointer := u.Fragment
+		u.Fragment = ""
+		base, schema = u, object(pointerValue(c[u.String()], pointer))
+	}
+	return base, nil
+}
+
+// property returns the schema of the named property, declared by the schema
+// or by one of its allOf subschemas.
+func (c coercer) property(base *url.URL, schema map[string]interface{}, name string) (*url.URL, map[string]interface{}) {
+	if properties := object(schema["properties"]); properties != nil {
+		if property, ok := properties[name]; ok {
+			return c.resolve(base, property)
+		}
+	}
+
+	for _, sub := range array(schema["allOf"]) {
+		subBase, subSchema := c.resolve(base, sub)
+		if propertyBase, property := c.property(subBase, subSchema, name); property != nil {
+			return propertyBase, property
+		}
+	}
+	return base, nil
+}
+
+// items returns the schema of the items of an array schema.
+func (c coercer) items(base *url.URL, schema map[string]interface{}) (*url.URL, map[string]interface{}) {
+	if items, ok := schema["items"]; ok {
+		return c.resolve(base, items)
+	}
+
+	for _, sub := range array(schema["allOf"]) {
+		subBase, subSchema := c.resolve(base, sub)
+		if itemsBase, items := c.items(subBase, subSchema); items != nil {
+			return itemsBase, items
+		}
+	}
+	return base, nil
+}
+
+// schemaType returns the type of the schema, the first one other than null
+// when it allows several, or the type of its allOf subschemas.
+func (c coercer) schemaType(base *url.URL, schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+
+	for _, sub := range array(schema["allOf"]) {
+		if t := c.schemaType(c.resolve(base, sub)); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// coerce converts the value to the JSON type, keeping it as a string when it
+// is not of that type. NaN and infinities are not JSON numbers, so they are
+// kept as strings too.
+func coerce(jsonType string, value string) interface{} {
+	switch jsonType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// pointerValue returns the value at the JSON pointer of the document, such as
+// "/definitions/amount", or nil when it is not found.
+func pointerValue(document interface{}, pointer string) interface{} {
+	if pointer == "" || pointer == "/" {
+		return document
+	}
+
+	value := document
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func object(value interface{}) map[string]interface{} {
+	o, _ := value.(map[string]interface{})
+	return o
+}
+
+func array(value interface{}) []interface{} {
+	a, _ := value.([]interface{})
+	return a
+}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -27,10 +28,17 @@ func (v ValidationError) ErrorsDescription() map[string]string {
 	for _, err := range v.Errors {
 		field := err.Field()
 		if err.Type() == "required" {
-			field = fmt.Sprintf("%s.%s", err.Context().String(), err.Field())
+			// The field of a required error is the object missing the property.
+			field = fmt.Sprintf("%s.%s", err.Context().String(), err.Details()["property"])
 			field = strings.Replace(field, "(root).", "", 1)
 		}
 
+		// A field failing several keywords, or several subschemas failing
+		// as a whole, report every error.
+		if description, exists := errors[field]; exists {
+			errors[field] = description + "; " + err.Description()
+			continue
+		}
 		errors[field] = err.Description()
 	}
 
@@ -48,12 +56,19 @@ func (v ValidationError) Error() string {
 }
 
 var (
-	m       sync.Mutex
-	schemas map[string]*gojsonschema.Schema
+	m          sync.Mutex
+	schemas    map[string]*gojsonschema.Schema
+	references map[string]string
+	documents  map[string]interface{}
 )
 
-// AddSchemaDir receives a path to a directory that contains only valid JSON schema
-// definitions. Each definition is compiled and stored in a map for later usage.
+// AddSchemaDir receives a path to a directory tree whose *.json files are
+// valid JSON schema definitions, draft-07 unless they declare another $schema.
+// The other files are ignored. Each definition is compiled and stored for later usage under its path
+// relative to the directory, such as "items/create.json". The definitions
+// can reference each other with $ref, either by a path relative to the file
+// holding the reference, as in "../common/money.json#/definitions/amount",
+// or by the absolute URI declared as the $id of the referenced file.
 func AddSchemaDir(dirname string) error {
 	m.Lock()
 	defer m.Unlock()
@@ -62,41 +77,82 @@ func AddSchemaDir(dirname string) error {
 		return err
 	}
 
-	files, err := ioutil.ReadDir(dirname)
+	root, err := filepath.Abs(dirname)
 	if err != nil {
 		return fmt.Errorf("error reading files from %s: %v", dirname, err)
 	}
 
-	schemas = map[string]*gojsonschema.Schema{}
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	// Every definition is added to the loader before compiling any, so that
+	// the references between them resolve regardless of their order.
+	loader := gojsonschema.NewSchemaLoader()
+	loader.Draft = gojsonschema.Draft7
+
+	files := map[string]string{}
+	loaded := map[string]interface{}{}
+	err = filepath.Walk(root, func(fullpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("error reading files from %s: %v", dirname, err)
+		}
+		if info.IsDir() || filepath.Ext(fullpath) != ".json" {
+			return nil
 		}
 
-		filename := file.Name()
+		name, err := filepath.Rel(root, fullpath)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
 
-		if schemas[filename] != nil {
-			return fmt.Errorf("schema key conflict: JSON schema %s already exists", filename)
+		bytes, err := ioutil.ReadFile(fullpath)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %v", name, err)
 		}
 
-		bytes, err := ioutil.ReadFile(path.Join(dirname, filename))
+		document, err := gojsonschema.NewBytesLoader(bytes).LoadJSON()
 		if err != nil {
-			return fmt.Errorf("error reading file %s: %v", file.Name(), err)
+			return fmt.Errorf("error reading JSON schema %s: %v", name, err)
+		}
+
+		reference := (&url.URL{Scheme: "file", Path: filepath.ToSlash(fullpath)}).String()
+		if err := loader.AddSchema(reference, gojsonschema.NewGoLoader(document)); err != nil {
+			return fmt.Errorf("error adding JSON schema %s: %v", name, err)
 		}
 
-		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(bytes))
+		files[name] = reference
+		loaded[reference] = document
+		if id, ok := object(document)["$id"].(string); ok {
+			if u, err := url.Parse(id); err == nil && u.IsAbs() {
+				u.Fragment = ""
+				loaded[u.String()] = document
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	compiled := make(map[string]*gojsonschema.Schema, len(files))
+	for name, reference := range files {
+		schema, err := loader.Compile(gojsonschema.NewReferenceLoader(reference))
 		if err != nil {
-			return fmt.Errorf("error compiling JSON schema %s: %v", filename, err)
+			return fmt.Errorf("error compiling JSON schema %s: %v", name, err)
 		}
 
-		schemas[filename] = schema
+		compiled[name] = schema
 	}
 
+	schemas = compiled
+	references = files
+	documents = loaded
 	return nil
 }
 
 // Schemas returns a slice containing all the currently available JSON schema definitions.
 func Schemas() []string {
+	m.Lock()
+	defer m.Unlock()
+
 	keys := make([]string, 0, len(schemas))
 
 	for k := range schemas {
@@ -114,9 +170,31 @@ func Validate(schemaName string, r io.Reader) error {
 	if err != nil {
 		return err
 	}
-	loader := gojsonschema.NewBytesLoader(bytes)
+	return validate(schemaName, gojsonschema.NewBytesLoader(bytes))
+}
+
+// ValidateValues validates string values, such as the query parameters of a
+// request, against the given schema. Each value is first converted to the
+// type of its property in the schema: integer, number, boolean, or an array
+// of those when the name has several values. The values that can't be
+// converted are kept as strings, failing the validation with a type error.
+// The types are looked up through the $ref and allOf of the schema.
+func ValidateValues(schemaName string, values map[string][]string) error {
+	m.Lock()
+	reference, exists := references[schemaName]
+	loaded := documents
+	m.Unlock()
+	if !exists {
+		return fmt.Errorf("JSON schema %s was not found", schemaName)
+	}
 
+	return validate(schemaName, gojsonschema.NewGoLoader(coerceValues(loaded, reference, values)))
+}
+
+func validate(schemaName string, loader gojsonschema.JSONLoader) error {
+	m.Lock()
 	schema, exists := schemas[schemaName]
+	m.Unlock()
 	if !exists {
 		return fmt.Errorf("JSON schema %s was not found", schemaName)
 	}
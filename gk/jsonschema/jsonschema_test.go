@@ -0,0 +1,169 @@
+package jsonschema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var schemaTree = map[string]string{
+	"common/money.json": `{
+		"definitions": {
+			"amount": {"type": "number", "minimum": 0}
+		}
+	}`,
+	"common/ids.json": `{
+		"$id": "https://schemas.mercadolibre.com/ids.json",
+		"type": "array",
+		"items": {"type": "integer"}
+	}`,
+	"common/address.json": `{
+		"$id": "https://schemas.mercadolibre.com/address.json",
+		"type": "object",
+		"required": ["city"],
+		"properties": {"city": {"type": "string"}}
+	}`,
+	"items/create.json": `{
+		"type": "object",
+		"required": ["price"],
+		"properties": {
+			"price": {"$ref": "../common/money.json#/definitions/amount"},
+			"address": {"$ref": "https://schemas.mercadolibre.com/address.json"},
+			"shipping": {"type": "string"},
+			"weight": {"type": "number", "minimum": 0, "multipleOf": 0.5}
+		},
+		"if": {"properties": {"shipping": {"const": "me2"}}, "required": ["shipping"]},
+		"then": {"required": ["weight"]}
+	}`,
+	"search.json": `{
+		"type": "object",
+		"definitions": {
+			"limit": {"type": "integer", "maximum": 50}
+		},
+		"properties": {
+			"price": {"$ref": "common/money.json#/definitions/amount"},
+			"ids": {"$ref": "https://schemas.mercadolibre.com/ids.json"}
+		},
+		"allOf": [
+			{"properties": {"limit": {"allOf": [{"$ref": "#/definitions/limit"}]}}}
+		]
+	}`,
+}
+
+func TestAddSchemaDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range schemaTree {
+		fullpath := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fullpath), 0755))
+		assert.NoError(t, ioutil.WriteFile(fullpath, []byte(content), 0644))
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# Schemas"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "items", ".create.json.swp"), []byte{0}, 0644))
+	assert.NoError(t, AddSchemaDir(dir))
+
+	names := Schemas()
+	sort.Strings(names)
+	assert.Equal(t, []string{
+		"common/address.json", "common/ids.json", "common/money.json", "items/create.json", "search.json",
+	}, names)
+
+	tt := []struct {
+		name   string
+		body   string
+		errors map[string]string
+	}{
+		{"valid", `{"price": 10, "address": {"city": "Buenos Aires"}}`, nil},
+		{"relative ref", `{"price": -1}`, map[string]string{
+			"price": "Must be greater than or equal to 0",
+		}},
+		{"absolute ref", `{"price": 1, "address": {}}`, map[string]string{
+			"address.city": "city is required",
+		}},
+		{"draft-07", `{"price": 1, "shipping": "me2"}`, map[string]string{
+			"weight": "weight is required",
+			"(root)": "Must validate \"then\" as \"if\" was valid",
+		}},
+		{"several errors", `{"price": 1, "weight": -0.3}`, map[string]string{
+			"weight": "Must be a multiple of 0.5; Must be greater than or equal to 0",
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate("items/create.json", strings.NewReader(tc.body))
+			if tc.errors == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			verr, ok := err.(*ValidationError)
+			if assert.True(t, ok, "expected a validation error, got %v", err) {
+				assert.Equal(t, tc.errors, verr.ErrorsDescription())
+			}
+		})
+	}
+}
+
+func TestValidateValuesRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range schemaTree {
+		fullpath := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fullpath), 0755))
+		assert.NoError(t, ioutil.WriteFile(fullpath, []byte(content), 0644))
+	}
+	assert.NoError(t, AddSchemaDir(dir))
+
+	tt := []struct {
+		name   string
+		values map[string][]string
+		errors map[string]string
+	}{
+		{"valid", map[string][]string{"price": {"9.5"}, "ids": {"1", "2"}, "limit": {"10"}}, nil},
+		{"relative ref", map[string][]string{"price": {"-1"}}, map[string]string{
+			"price": "Must be greater than or equal to 0",
+		}},
+		{"absolute ref", map[string][]string{"ids": {"1", "two"}}, map[string]string{
+			"ids.1": "Invalid type. Expected: integer, given: string",
+		}},
+		{"allOf", map[string][]string{"limit": {"100"}}, map[string]string{
+			"limit":  "Must be less than or equal to 50; Must validate all the schemas (allOf)",
+			"(root)": "Must validate all the schemas (allOf)",
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateValues("search.json", tc.values)
+			if tc.errors == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			verr, ok := err.(*ValidationError)
+			if assert.True(t, ok, "expected a validation error, got %v", err) {
+				assert.Equal(t, tc.errors, verr.ErrorsDescription())
+			}
+		})
+	}
+}
+
+func TestAddSchemaDirMissingRef(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := `{"properties": {"price": {"$ref": "money.json"}}}`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "item.json"), []byte(content), 0644))
+	assert.Error(t, AddSchemaDir(dir))
+}
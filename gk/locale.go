@@ -0,0 +1,118 @@
+package gk
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/babel"
+	"golang.org/x/text/language"
+)
+
+// Keys the Locale middleware stores the negotiated locale and the translator
+// under, in the gin context.
+const (
+	localeKey     = "Locale"
+	translatorKey = "Translator"
+)
+
+// SiteLocales maps each site to the locale its users are served in.
+var SiteLocales = map[string]language.Tag{
+	"MLA": language.MustParse("es-AR"),
+	"MLB": language.MustParse("pt-BR"),
+	"MLC": language.MustParse("es-CL"),
+	"MCO": language.MustParse("es-CO"),
+	"MLM": language.MustParse("es-MX"),
+	"MPE": language.MustParse("es-PE"),
+	"MLU": language.MustParse("es-UY"),
+}
+
+// localeSettings contains the sources the Locale middleware negotiates from.
+type localeSettings struct {
+	QueryParam    string
+	SiteHeader    string
+	Sites         map[string]language.Tag
+	DefaultLocale language.Tag
+	Translator    *babel.Translator
+}
+
+// LocaleOpt is a function for the Locale middleware, used for overriding its
+// default settings.
+type LocaleOpt func(*localeSettings)
+
+// WithLocaleQueryParam sets the query parameter that explicitly selects a locale.
+func WithLocaleQueryParam(name string) LocaleOpt {
+	return func(s *localeSettings) {
+		s.QueryParam = name
+	}
+}
+
+// WithSiteHeader sets the header holding the site of the request, and the
+// locale of each site.
+func WithSiteHeader(name string, sites map[string]language.Tag) LocaleOpt {
+	return func(s *localeSettings) {
+		s.SiteHeader = name
+		s.Sites = sites
+	}
+}
+
+// WithDefaultLocale sets the locale used when no source matches a loaded locale.
+func WithDefaultLocale(locale language.Tag) LocaleOpt {
+	return func(s *localeSettings) {
+		s.DefaultLocale = locale
+	}
+}
+
+// WithTranslator sets the translator whose loaded locales are negotiated, and
+// that the gk.Context helpers translate with.
+func WithTranslator(translator *babel.Translator) LocaleOpt {
+	return func(s *localeSettings) {
+		s.Translator = translator
+	}
+}
+
+// Locale is a middleware that negotiates the locale of the request and makes
+// it available to the handlers through gk.Context. The locale is taken from,
+// in order, the query parameter, the site header and the Accept-Language
+// header, skipping the ones the translator has no translations for.
+func Locale(opts ...LocaleOpt) gin.HandlerFunc {
+	settings := &localeSettings{
+		QueryParam:    "locale",
+		SiteHeader:    "X-Site-Id",
+		Sites:         SiteLocales,
+		DefaultLocale: language.Und,
+		Translator:    babel.Default(),
+	}
+	for _, opt := range opts {
+		opt(settings)
+	}
+
+	return func(c *gin.Context) {
+		c.Set(localeKey, settings.negotiate(c))
+		c.Set(translatorKey, settings.Translator)
+		c.Next()
+	}
+}
+
+func (s *localeSettings) negotiate(c *gin.Context) language.Tag {
+	var candidates []language.Tag
+
+	if value := c.Query(s.QueryParam); value != "" {
+		if tag, err := language.Parse(value); err == nil {
+			candidates = append(candidates, tag)
+		}
+	}
+
+	if tag, ok := s.Sites[c.GetHeader(s.SiteHeader)]; ok {
+		candidates = append(candidates, tag)
+	}
+
+	// tags come sorted by quality, invalid headers are ignored
+	if tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language")); err == nil {
+		candidates = append(candidates, tags...)
+	}
+
+	for _, tag := range candidates {
+		if s.Translator.Supports(tag) {
+			return tag
+		}
+	}
+	return s.DefaultLocale
+}
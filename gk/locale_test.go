@@ -0,0 +1,63 @@
+package gk_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/babel"
+	"github.com/mercadolibre/coreservices-team/gk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestLocale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for locale, translation := range map[string]string{"es-AR": "Hola", "pt-BR": "Olá"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, locale), 0777))
+		content := []byte("msgid \"Hello\"\nmsgstr \"" + translation + "\"\n")
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, locale, "messages.po"), content, 0666))
+	}
+
+	translator := babel.NewTranslator()
+	require.NoError(t, translator.LoadDir(dir))
+
+	tt := []struct {
+		Name     string
+		URL      string
+		Headers  map[string]string
+		Expected string
+	}{
+		{"Query param", "/?locale=pt-BR", map[string]string{"Accept-Language": "es-AR"}, "Olá"},
+		{"Unsupported query param", "/?locale=fr", map[string]string{"X-Site-Id": "MLB"}, "Olá"},
+		{"Site header", "/", map[string]string{"X-Site-Id": "MLA", "Accept-Language": "pt-BR"}, "Hola"},
+		{"Accept-Language", "/", map[string]string{"Accept-Language": "fr;q=0.9, es-UY;q=0.8"}, "Hola"},
+		{"No match", "/", map[string]string{"Accept-Language": "fr"}, "Hello"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Request, _ = http.NewRequest("GET", tc.URL, nil)
+			for k, v := range tc.Headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			gk.Locale(gk.WithTranslator(translator))(c)
+			gk.Handler(func(c *gin.Context, ctx *gk.Context) {
+				assert.Equal(t, tc.Expected, ctx.Tr("Hello"))
+			})(c)
+		})
+	}
+
+	assert.Equal(t, language.Und, gk.CreateTestContext().Locale)
+}
@@ -0,0 +1,77 @@
+package gk
+
+import (
+	"context"
+	"time"
+
+	"github.com/mercadolibre/coreservices-team/libs/go/logger"
+)
+
+type handlerSettings struct {
+	timeout time.Duration
+}
+
+// HandlerOpt configures a Handler.
+type HandlerOpt func(*handlerSettings)
+
+// WithTimeout sets the deadline of the request context of the route, so that
+// the calls made with it are cancelled once the timeout expires.
+func WithTimeout(timeout time.Duration) HandlerOpt {
+	return func(s *handlerSettings) {
+		s.timeout = timeout
+	}
+}
+
+type contextKey int
+
+const (
+	gkContextKey contextKey = iota
+	requestIDKey
+	callerKey
+	loggerKey
+)
+
+// Context returns the context of the request, to be passed to the KVS, DS,
+// SQL and HTTP calls made while handling it. It is cancelled when the client
+// goes away, when the route timeout expires and once the handler returns.
+// The request ID, caller and logger are available in it as values.
+func (c *Context) Context() context.Context {
+	if c.ctx == nil {
+		return c.withValues(context.Background())
+	}
+	return c.ctx
+}
+
+func (c *Context) withValues(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, gkContextKey, c)
+	ctx = context.WithValue(ctx, requestIDKey, c.RequestID)
+	ctx = context.WithValue(ctx, callerKey, c.Caller)
+	return context.WithValue(ctx, loggerKey, c.Log)
+}
+
+// FromContext returns the Context of the request a context was derived from.
+func FromContext(ctx context.Context) (*Context, bool) {
+	c, ok := ctx.Value(gkContextKey).(*Context)
+	return c, ok
+}
+
+// RequestIDFromContext returns the ID of the request a context was derived
+// from, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// CallerFromContext returns the caller of the request a context was derived
+// from.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey).(Caller)
+	return caller, ok
+}
+
+// LoggerFromContext returns the logger of the request a context was derived
+// from, or nil.
+func LoggerFromContext(ctx context.Context) *logger.Logger {
+	log, _ := ctx.Value(loggerKey).(*logger.Logger)
+	return log
+}
@@ -2,16 +2,19 @@ package gk
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mercadolibre/coreservices-team/gk/jsonschema"
 	"github.com/mercadolibre/coreservices-team/libs/go/errors"
 )
 
-// JSONSchema is a middleware that accepts a JSON schema name  that must
-// be a valid JSON Schema (Draft #6) definition. It then uses this schema
-// to validate the request body. It returns status 422 on failure.
+// JSONSchema is a middleware that accepts a JSON schema name, its path in the
+// schema directory, that must be a valid JSON Schema (draft-07) definition.
+// It then uses this schema to validate the request body. It returns status
+// 422 on failure, and 500 when the schema is not loaded.
 func JSONSchema(schemaName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		body, err := ioutil.ReadAll(c.Request.Body)
@@ -26,28 +29,87 @@ func JSONSchema(schemaName string) gin.HandlerFunc {
 		}
 		c.Request.Body.Close()
 
-		buf := bytes.NewReader(body)
-		if err := jsonschema.Validate(schemaName, buf); err != nil {
-			values := map[string]string{}
+		if !validateBody(c, schemaName, body) {
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
 
-			if verr, ok := err.(*jsonschema.ValidationError); ok {
-				values = verr.ErrorsDescription()
+		c.Next()
+	}
+}
+
+// ParamsSchema is a middleware that validates the query parameters, the path
+// parameters and the given headers of the request against a JSON schema,
+// the same way JSONSchema validates the body. They are validated as a single
+// JSON object with a property per parameter and header, named as given,
+// converted to the types of the schema properties. It returns status 422 on
+// failure, and 500 when the schema is not loaded.
+func ParamsSchema(schemaName string, headers ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		values := map[string][]string{}
+		for name, list := range c.Request.URL.Query() {
+			values[name] = list
+		}
+		for _, param := range c.Params {
+			values[param.Key] = []string{param.Value}
+		}
+		for _, header := range headers {
+			if list, ok := c.Request.Header[http.CanonicalHeaderKey(header)]; ok {
+				values[header] = list
 			}
+		}
 
-			errors.ReturnError(c, &errors.Error{
-				Code:    errors.UnprocessableEntityApiError,
-				Message: "Error validating body to JSON schema",
-				Cause:   "Validation error",
-				Values:  values,
-			})
+		if err := jsonschema.ValidateValues(schemaName, values); err != nil {
+			returnSchemaError(c, "Error validating parameters to JSON schema", err)
 			c.Abort()
 			return
 		}
 
-		// Rewind body buffer, encapsulate it in a NopCloser, and assign it to request body again.
-		buf.Seek(0, 0)
-		c.Request.Body = ioutil.NopCloser(buf)
-
 		c.Next()
 	}
 }
+
+// validateBody validates the JSON body against the schema. It responds with
+// the error and returns false when the body is not valid.
+func validateBody(c *gin.Context, schemaName string, body []byte) bool {
+	// gojsonschema fails without per-field errors on malformed JSON
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		returnValidationError(c, "Error decoding JSON body", decodeErrors(err))
+		return false
+	}
+
+	err := jsonschema.Validate(schemaName, bytes.NewReader(body))
+	if err == nil {
+		return true
+	}
+	returnSchemaError(c, "Error validating body to JSON schema", err)
+	return false
+}
+
+// returnSchemaError responds with a 422 status and the per-field errors of a
+// jsonschema validation error, or with a 500 status when the validation
+// could not run, such as when the schema is not loaded.
+func returnSchemaError(c *gin.Context, message string, err error) {
+	if verr, ok := err.(*jsonschema.ValidationError); ok {
+		returnValidationError(c, message, verr.ErrorsDescription())
+		return
+	}
+	errors.ReturnError(c, &errors.Error{
+		Code:    errors.InternalServerApiError,
+		Message: message,
+		Cause:   err.Error(),
+	})
+}
+
+// returnValidationError responds with a 422 status and the per-field errors.
+func returnValidationError(c *gin.Context, message string, values map[string]string) {
+	errors.ReturnError(c, &errors.Error{
+		Code:    errors.UnprocessableEntityApiError,
+		Message: message,
+		Cause:   "Validation error",
+		Values:  values,
+	})
+}
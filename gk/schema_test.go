@@ -0,0 +1,108 @@
+package gk_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/coreservices-team/gk"
+	"github.com/mercadolibre/coreservices-team/gk/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+const searchSchema = `{
+	"type": "object",
+	"required": ["site_id", "X-Caller-Id"],
+	"properties": {
+		"site_id": {"type": "string", "pattern": "^M[A-Z]{2}$"},
+		"limit": {"type": "integer", "minimum": 1, "maximum": 50},
+		"price": {"type": "number"},
+		"active": {"type": "boolean"},
+		"ids": {"type": "array", "items": {"type": "integer"}},
+		"X-Caller-Id": {"type": "integer"}
+	}
+}`
+
+func TestParamsSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "search.json"), []byte(searchSchema), 0644))
+	assert.NoError(t, jsonschema.AddSchemaDir(dir))
+
+	tt := []struct {
+		name   string
+		path   string
+		caller string
+		status int
+		values map[string]string
+	}{
+		{"valid", "/sites/MLA/search?limit=10&price=9.5&active=true&ids=1&ids=2", "1", http.StatusOK, nil},
+		{"path", "/sites/ARG/search", "1", http.StatusUnprocessableEntity, map[string]string{
+			"site_id": "Does not match pattern '^M[A-Z]{2}$'",
+		}},
+		{"range", "/sites/MLA/search?limit=100", "1", http.StatusUnprocessableEntity, map[string]string{
+			"limit": "Must be less than or equal to 50",
+		}},
+		{"type", "/sites/MLA/search?active=yes", "1", http.StatusUnprocessableEntity, map[string]string{
+			"active": "Invalid type. Expected: boolean, given: string",
+		}},
+		{"not a number", "/sites/MLA/search?price=NaN", "1", http.StatusUnprocessableEntity, map[string]string{
+			"price": "Invalid type. Expected: number, given: string",
+		}},
+		{"infinity", "/sites/MLA/search?price=-Inf", "1", http.StatusUnprocessableEntity, map[string]string{
+			"price": "Invalid type. Expected: number, given: string",
+		}},
+		{"array", "/sites/MLA/search?ids=1&ids=two", "1", http.StatusUnprocessableEntity, map[string]string{
+			"ids.1": "Invalid type. Expected: integer, given: string",
+		}},
+		{"header", "/sites/MLA/search", "", http.StatusUnprocessableEntity, map[string]string{
+			"X-Caller-Id": "X-Caller-Id is required",
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/sites/:site_id/search", gk.ParamsSchema("search.json", "X-Caller-Id"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			if tc.caller != "" {
+				req.Header.Set("X-Caller-Id", tc.caller)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tc.status, rr.Code)
+
+			if tc.values != nil {
+				var response struct {
+					Error  string            `json:"error"`
+					Values map[string]string `json:"values"`
+				}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, "UnprocessableEntityApiError", response.Error)
+				assert.Equal(t, tc.values, response.Values)
+			}
+		})
+	}
+}
+
+func TestParamsSchemaMissingSchema(t *testing.T) {
+	router := gin.New()
+	router.GET("/sites/:site_id/search", gk.ParamsSchema("missing.json"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/sites/MLA/search", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
@@ -165,7 +165,7 @@ func WithDebug(debug bool) Opt {
 
 // WithJSONSchemaDir func reads the given directory, and initializes
 // gordik's jsonschema package with support for all .json schema
-// definitions found in there.
+// definitions found in there and its subdirectories.
 func WithJSONSchemaDir(path string) Opt {
 	return func(s *Server) {
 		if err := jsonschema.AddSchemaDir(path); err != nil {